@@ -10,6 +10,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 )
 
 type StaticFileInfo struct {
@@ -20,9 +21,34 @@ type StaticFileInfo struct {
 }
 
 type TemplateData struct {
-	Global map[string]any
-	Page   map[string]any
-	Static map[string]StaticFileInfo
+	Global    map[string]any
+	Data      map[string]any // decoded files of the data directory
+	Page      map[string]any
+	Static    map[string]StaticFileInfo
+	Paginator *Paginator           // nil unless the page is paginated
+	Content   template.HTML        // rendered Markdown body of content pages
+	Pages     []*PageInfo          // every page of the site, in config order
+	Current   *PageInfo            // the page being rendered, also present in Pages
+	Feeds     map[string]*FeedInfo // feed URLs by feed name
+}
+
+// PageInfo describes one page of the site for listings and navigation.
+type PageInfo struct {
+	Output   string         // output path relative to the output directory
+	URL      string         // site-relative URL, "/blog/" for "blog/index.html"
+	Template string         // template file name
+	Data     map[string]any // page data, including fetched values
+	Content  template.HTML  // rendered Markdown body of content pages
+}
+
+func newPageInfo(page PageConfig) *PageInfo {
+	return &PageInfo{
+		Output:   page.Output,
+		URL:      outputURL(page.Output),
+		Template: page.Template,
+		Data:     page.Data,
+		Content:  page.content,
+	}
 }
 
 //nolint:gochecknoglobals
@@ -43,28 +69,89 @@ var funcMap = template.FuncMap{
 
 var errNotDirectory = errors.New("path is not a directory")
 
-func RenderPage(templateDir string, page PageConfig, globalLayout string, data TemplateData, outputDir string) error {
+// Renderer renders pages from a template directory. Shared files (_*.html)
+// are parsed once; each page template is parsed on top of a clone of them the
+// first time it is used and cached by name. A Renderer is safe for concurrent
+// use.
+type Renderer struct {
+	templateDir string
+	shared      *template.Template
+
+	mu     sync.Mutex
+	pages  map[string]*template.Template
+	fields map[string]map[string]bool // shared TemplateData fields used, by page template
+}
+
+func NewRenderer(templateDir string) (*Renderer, error) {
 	tmpl := template.New("").Funcs(funcMap)
 
 	// Parse all shared files (_*.html)
 	sharedPattern := filepath.Join(templateDir, "_*.html")
 	sharedFiles, err := filepath.Glob(sharedPattern)
 	if err != nil {
-		return fmt.Errorf("glob shared templates: %w", err)
+		return nil, fmt.Errorf("glob shared templates: %w", err)
 	}
 
 	if len(sharedFiles) > 0 {
 		tmpl, err = tmpl.ParseFiles(sharedFiles...)
 		if err != nil {
-			return fmt.Errorf("parse shared templates: %w", err)
+			return nil, fmt.Errorf("parse shared templates: %w", err)
 		}
 	}
 
+	return &Renderer{
+		templateDir: templateDir,
+		shared:      tmpl,
+		pages:       make(map[string]*template.Template),
+		fields:      make(map[string]map[string]bool),
+	}, nil
+}
+
+// pageTemplate returns the shared templates combined with the page template.
+func (r *Renderer) pageTemplate(name string) (*template.Template, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if tmpl, ok := r.pages[name]; ok {
+		return tmpl, nil
+	}
+
+	tmpl, err := r.shared.Clone()
+	if err != nil {
+		return nil, fmt.Errorf("clone shared templates: %w", err)
+	}
+
 	// Parse the page template
-	pageTemplatePath := filepath.Join(templateDir, page.Template)
+	pageTemplatePath := filepath.Join(r.templateDir, name)
 	tmpl, err = tmpl.ParseFiles(pageTemplatePath)
 	if err != nil {
-		return fmt.Errorf("parse page template %s: %w", page.Template, err)
+		return nil, fmt.Errorf("parse page template %s: %w", name, err)
+	}
+
+	r.pages[name] = tmpl
+	// Inspect the parse trees now: html/template rewrites them on first use.
+	r.fields[name] = sharedFieldsUsed(tmpl)
+
+	return tmpl, nil
+}
+
+// usedFields returns the fields of TemplateData shared by every page (see
+// sharedFields) that the page template, its layout or a shared file may read.
+func (r *Renderer) usedFields(name string) (map[string]bool, error) {
+	if _, err := r.pageTemplate(name); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.fields[name], nil
+}
+
+func (r *Renderer) Render(page PageConfig, globalLayout string, data TemplateData, outputDir string) error {
+	tmpl, err := r.pageTemplate(page.Template)
+	if err != nil {
+		return err
 	}
 
 	// Determine layout
@@ -99,6 +186,17 @@ func RenderPage(templateDir string, page PageConfig, globalLayout string, data T
 	return nil
 }
 
+// RenderPage renders a single page. Use a Renderer to render many pages from
+// the same template directory without re-parsing the shared templates.
+func RenderPage(templateDir string, page PageConfig, globalLayout string, data TemplateData, outputDir string) error {
+	r, err := NewRenderer(templateDir)
+	if err != nil {
+		return err
+	}
+
+	return r.Render(page, globalLayout, data, outputDir)
+}
+
 func CopyStatic(staticDir, outputDir string) error {
 	info, err := os.Stat(staticDir)
 	if err != nil {
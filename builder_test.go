@@ -1,9 +1,11 @@
 package ssssg
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 )
 
@@ -384,3 +386,108 @@ func TestCopyStatic_NonexistentDir(t *testing.T) {
 		t.Errorf("CopyStatic should not error for nonexistent dir: %v", err)
 	}
 }
+
+func TestRenderer_ReusesTemplatesAcrossPages(t *testing.T) {
+	t.Parallel()
+
+	dir := setupTemplateDir(t)
+	tmplDir := filepath.Join(dir, "templates")
+	outputDir := filepath.Join(dir, "public")
+
+	about := `{{ define "content" }}<h2>{{ .Page.greeting }}</h2>{{ end }}`
+	if err := os.WriteFile(filepath.Join(tmplDir, "about.html"), []byte(about), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewRenderer(tmplDir)
+	if err != nil {
+		t.Fatalf("NewRenderer failed: %v", err)
+	}
+
+	// Render many pages concurrently; index.html and about.html both define
+	// "content" and must not leak into each other.
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+
+	for i := range 20 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			page := PageConfig{Template: "index.html", Output: fmt.Sprintf("index-%d.html", i)}
+			if i%2 == 1 {
+				page = PageConfig{Template: "about.html", Output: fmt.Sprintf("about-%d.html", i)}
+			}
+
+			data := TemplateData{
+				Global: map[string]any{"site_name": "Test Site"},
+				Page:   map[string]any{"title": "T", "greeting": fmt.Sprintf("g%d", i)},
+			}
+
+			errs <- r.Render(page, "_layout.html", data, outputDir)
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Render failed: %v", err)
+		}
+	}
+
+	index, err := os.ReadFile(filepath.Join(outputDir, "index-4.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(index), "<h1>g4</h1>") || !strings.Contains(string(index), "<header><nav>Test Site</nav></header>") {
+		t.Errorf("unexpected index output:\n%s", index)
+	}
+
+	aboutOut, err := os.ReadFile(filepath.Join(outputDir, "about-5.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(aboutOut), "<h2>g5</h2>") {
+		t.Errorf("unexpected about output:\n%s", aboutOut)
+	}
+
+	// Page templates are cached: later edits on disk are not picked up
+	if err := os.WriteFile(filepath.Join(tmplDir, "index.html"), []byte(`{{ define "content" }}changed{{ end }}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	data := TemplateData{Page: map[string]any{"greeting": "cached"}}
+	if err := r.Render(PageConfig{Template: "index.html", Output: "cached.html"}, "_layout.html", data, outputDir); err != nil {
+		t.Fatal(err)
+	}
+
+	cached, err := os.ReadFile(filepath.Join(outputDir, "cached.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(cached), "<h1>cached</h1>") {
+		t.Errorf("page template should be cached:\n%s", cached)
+	}
+}
+
+func TestRenderer_MissingPageTemplate(t *testing.T) {
+	t.Parallel()
+
+	dir := setupTemplateDir(t)
+
+	r, err := NewRenderer(filepath.Join(dir, "templates"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = r.Render(PageConfig{Template: "missing.html", Output: "x.html"}, "", TemplateData{}, filepath.Join(dir, "public"))
+	if err == nil || !strings.Contains(err.Error(), "missing.html") {
+		t.Fatalf("expected parse error for missing template, got %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package ssssg
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/encoding"
+)
+
+// sniffLen is how much of a document is searched for a declared charset.
+const sniffLen = 1024
+
+var errEncodingUnknown = errors.New("unknown encoding")
+
+// declaredCharset matches <meta charset="...">, the charset of a
+// <meta http-equiv="Content-Type"> content attribute and the encoding of an
+// XML declaration.
+//
+//nolint:gochecknoglobals
+var declaredCharset = regexp.MustCompile(`(?i)<meta[^>]*?charset\s*=\s*["']?\s*([\w.:-]+)|^\s*<\?xml[^>]*?encoding\s*=\s*["']([\w.:-]+)`)
+
+// toUTF8 transcodes fetched content to UTF-8. The charset is taken from
+// encoding if set, then from the charset parameter of contentType, a byte
+// order mark, or a charset declared in an HTML or XML document. Content
+// without any of these is returned unchanged.
+func toUTF8(body, contentType, encodingName string) (string, error) {
+	enc, name, err := detectCharset(body, contentType, encodingName)
+	if err != nil || enc == nil || name == "utf-8" {
+		return body, err
+	}
+
+	out, err := enc.NewDecoder().String(body)
+	if err != nil {
+		return "", fmt.Errorf("decode %s: %w", name, err)
+	}
+
+	return out, nil
+}
+
+func detectCharset(body, contentType, encodingName string) (encoding.Encoding, string, error) {
+	if encodingName != "" {
+		enc, name := charset.Lookup(encodingName)
+		if enc == nil {
+			return nil, "", fmt.Errorf("%w: %s", errEncodingUnknown, encodingName)
+		}
+
+		return enc, name, nil
+	}
+
+	head := body
+	if len(head) > sniffLen {
+		head = head[:sniffLen]
+	}
+
+	// Only a byte order mark or a charset parameter makes DetermineEncoding
+	// certain; its guesses are ignored.
+	if enc, name, certain := charset.DetermineEncoding([]byte(head), contentType); certain {
+		return enc, name, nil
+	}
+
+	if contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err == nil && !strings.Contains(mediaType, "html") && !strings.Contains(mediaType, "xml") {
+			return nil, "", nil
+		}
+	}
+
+	if m := declaredCharset.FindStringSubmatch(head); m != nil {
+		label := m[1] + m[2]
+
+		if enc, name := charset.Lookup(label); enc != nil {
+			return enc, name, nil
+		}
+	}
+
+	return nil, "", nil
+}
+
+func validateEncoding(entry FetchEntry) error {
+	if entry.Encoding == "" {
+		return nil
+	}
+
+	if enc, _ := charset.Lookup(entry.Encoding); enc == nil {
+		return fmt.Errorf("%w: %s", errEncodingUnknown, entry.Encoding)
+	}
+
+	return nil
+}
@@ -0,0 +1,176 @@
+package ssssg
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+)
+
+func encodeString(t *testing.T, enc encoding.Encoding, s string) string {
+	t.Helper()
+
+	out, err := enc.NewEncoder().String(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return out
+}
+
+func TestToUTF8(t *testing.T) {
+	t.Parallel()
+
+	const ja = "日本語のページ"
+
+	sjis := encodeString(t, japanese.ShiftJIS, ja)
+	eucjp := encodeString(t, japanese.EUCJP, ja)
+	latin1 := encodeString(t, charmap.ISO8859_1, "café")
+
+	tests := []struct {
+		name        string
+		body        string
+		contentType string
+		encoding    string
+		want        string
+	}{
+		{
+			name:        "content type",
+			body:        sjis,
+			contentType: "text/plain; charset=Shift_JIS",
+			want:        ja,
+		},
+		{
+			name:        "content type wins over meta",
+			body:        `<meta charset="utf-8">` + eucjp,
+			contentType: "text/html; charset=euc-jp",
+			want:        `<meta charset="utf-8">` + ja,
+		},
+		{
+			name: "meta charset",
+			body: `<html><head><meta charset="EUC-JP"></head><body>` + eucjp,
+			want: `<html><head><meta charset="EUC-JP"></head><body>` + ja,
+		},
+		{
+			name:        "meta http-equiv",
+			body:        `<meta http-equiv="Content-Type" content="text/html; charset=shift_jis">` + sjis,
+			contentType: "text/html",
+			want:        `<meta http-equiv="Content-Type" content="text/html; charset=shift_jis">` + ja,
+		},
+		{
+			name: "xml declaration",
+			body: `<?xml version="1.0" encoding="ISO-8859-1"?><title>` + latin1 + `</title>`,
+			want: `<?xml version="1.0" encoding="ISO-8859-1"?><title>café</title>`,
+		},
+		{
+			name:     "explicit encoding",
+			body:     sjis,
+			encoding: "sjis",
+			want:     ja,
+		},
+		{
+			name: "undeclared content is unchanged",
+			body: sjis,
+			want: sjis,
+		},
+		{
+			name:        "declarations in other media types are ignored",
+			body:        `{"html": "<meta charset=euc-jp>"}`,
+			contentType: "application/json",
+			want:        `{"html": "<meta charset=euc-jp>"}`,
+		},
+		{
+			name:        "utf-8",
+			body:        ja,
+			contentType: "text/html; charset=utf-8",
+			want:        ja,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := toUTF8(tt.body, tt.contentType, tt.encoding)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToUTF8_UnknownEncoding(t *testing.T) {
+	t.Parallel()
+
+	if _, err := toUTF8("x", "", "klingon"); !errors.Is(err, errEncodingUnknown) {
+		t.Errorf("expected errEncodingUnknown, got %v", err)
+	}
+
+	if err := validateEncoding(FetchEntry{Encoding: "klingon"}); !errors.Is(err, errEncodingUnknown) {
+		t.Errorf("expected errEncodingUnknown, got %v", err)
+	}
+}
+
+func TestFetcher_ConvertsBeforeCaching(t *testing.T) {
+	t.Parallel()
+
+	const ja = "お知らせ"
+
+	body := encodeString(t, japanese.ShiftJIS, ja)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=Shift_JIS")
+		_, _ = w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	cacheDir := filepath.Join(dir, "cache")
+
+	if err := os.WriteFile(filepath.Join(dir, "legacy.txt"), []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	online := NewFetcher(dir, srv.Client(), WithCacheDir(cacheDir))
+
+	got, err := online.Resolve(context.Background(), FetchEntry{URL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got != ja {
+		t.Errorf("online: got %q, want %q", got, ja)
+	}
+
+	// The cached body is UTF-8 already and must not be converted twice
+	offline := NewFetcher(dir, nil, WithCacheDir(cacheDir), WithOffline(true))
+
+	got, err = offline.Resolve(context.Background(), FetchEntry{URL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got != ja {
+		t.Errorf("offline: got %q, want %q", got, ja)
+	}
+
+	got, err = online.Resolve(context.Background(), FetchEntry{URL: "legacy.txt", Encoding: "Shift_JIS"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got != ja {
+		t.Errorf("file: got %q, want %q", got, ja)
+	}
+}
@@ -2,10 +2,14 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
 	"runtime"
 	"runtime/debug"
+	"strconv"
+	"syscall"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -19,6 +23,8 @@ var (
 	date    = "unknown"
 )
 
+var errNegativeFlag = errors.New("must not be negative")
+
 func getVersion() string {
 	if version != "dev" {
 		return version
@@ -80,10 +86,11 @@ func main() {
 	}
 
 	buildCmd := newBuildCmd()
+	serveCmd := newServeCmd()
 	initCmd := newInitCmd()
 	versionCmd := newVersionCmd()
 
-	rootCmd.AddCommand(buildCmd, initCmd, versionCmd)
+	rootCmd.AddCommand(buildCmd, serveCmd, initCmd, versionCmd)
 
 	if err := rootCmd.Execute(); err != nil {
 		os.Exit(1)
@@ -95,10 +102,16 @@ func newBuildCmd() *cobra.Command {
 		configPath  string
 		templateDir string
 		staticDir   string
+		contentDir  string
+		dataDir     string
 		outputDir   string
 		timeout     time.Duration
 		clean       bool
 		parallelism int
+		cacheDir    string
+		force       bool
+		offline     bool
+		fetchOpts   fetchFlags
 	)
 
 	cmd := &cobra.Command{
@@ -109,11 +122,22 @@ func newBuildCmd() *cobra.Command {
 				ConfigPath:  configPath,
 				TemplateDir: templateDir,
 				StaticDir:   staticDir,
+				ContentDir:  contentDir,
+				DataDir:     dataDir,
 				OutputDir:   outputDir,
 				Timeout:     timeout,
 				Clean:       clean,
 				Log:         os.Stdout,
 				Parallelism: parallelism,
+				CacheDir:    cacheDir,
+				Force:       force,
+				Offline:     offline,
+
+				FetchTimeout:       fetchOpts.timeout,
+				FetchRetries:       fetchOpts.retries.value(),
+				FetchBackoff:       fetchOpts.backoff,
+				FetchRetryStatuses: fetchOpts.retryStatuses,
+				FetchMaxBodySize:   int64(fetchOpts.maxBodySize),
 			})
 		},
 	}
@@ -121,14 +145,177 @@ func newBuildCmd() *cobra.Command {
 	cmd.Flags().StringVar(&configPath, "config", "site.yaml", "path to config file")
 	cmd.Flags().StringVar(&templateDir, "templates", "", "path to templates directory")
 	cmd.Flags().StringVar(&staticDir, "static", "", "path to static directory")
+	cmd.Flags().StringVar(&contentDir, "content", "", "path to Markdown content directory")
+	cmd.Flags().StringVar(&dataDir, "data", "", "path to data directory")
 	cmd.Flags().StringVar(&outputDir, "output", "", "path to output directory")
-	cmd.Flags().DurationVar(&timeout, "timeout", 30*time.Second, "timeout for HTTP fetches")
+	cmd.Flags().DurationVar(&timeout, "timeout", 30*time.Second, "timeout for the whole build, including all HTTP fetches")
 	cmd.Flags().BoolVar(&clean, "clean", false, "remove output directory before building")
 	cmd.Flags().IntVar(&parallelism, "parallelism", 0, "max number of parallel operations (0 = number of CPUs)")
+	cmd.Flags().StringVar(&cacheDir, "cache-dir", "", "path to build cache directory")
+	cmd.Flags().BoolVar(&force, "force", false, "ignore the build cache and rebuild everything")
+	cmd.Flags().BoolVar(&offline, "offline", false, "serve remote fetch sources only from the fetch cache")
+	fetchOpts.register(cmd)
 
 	return cmd
 }
 
+func newServeCmd() *cobra.Command {
+	var (
+		configPath   string
+		templateDir  string
+		staticDir    string
+		contentDir   string
+		dataDir      string
+		outputDir    string
+		timeout      time.Duration
+		parallelism  int
+		cacheDir     string
+		force        bool
+		offline      bool
+		fetchOpts    fetchFlags
+		addr         string
+		pollInterval time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve the site locally and rebuild on changes",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			return ssssg.Serve(ctx, ssssg.ServeOptions{
+				BuildOptions: ssssg.BuildOptions{
+					ConfigPath:  configPath,
+					TemplateDir: templateDir,
+					StaticDir:   staticDir,
+					ContentDir:  contentDir,
+					DataDir:     dataDir,
+					OutputDir:   outputDir,
+					Timeout:     timeout,
+					Log:         os.Stdout,
+					Parallelism: parallelism,
+					CacheDir:    cacheDir,
+					Force:       force,
+					Offline:     offline,
+
+					FetchTimeout:       fetchOpts.timeout,
+					FetchRetries:       fetchOpts.retries.value(),
+					FetchBackoff:       fetchOpts.backoff,
+					FetchRetryStatuses: fetchOpts.retryStatuses,
+					FetchMaxBodySize:   int64(fetchOpts.maxBodySize),
+				},
+				Addr:         addr,
+				PollInterval: pollInterval,
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&configPath, "config", "site.yaml", "path to config file")
+	cmd.Flags().StringVar(&templateDir, "templates", "", "path to templates directory")
+	cmd.Flags().StringVar(&staticDir, "static", "", "path to static directory")
+	cmd.Flags().StringVar(&contentDir, "content", "", "path to Markdown content directory")
+	cmd.Flags().StringVar(&dataDir, "data", "", "path to data directory")
+	cmd.Flags().StringVar(&outputDir, "output", "", "path to output directory")
+	cmd.Flags().DurationVar(&timeout, "timeout", 30*time.Second, "timeout for the whole build, including all HTTP fetches")
+	cmd.Flags().IntVar(&parallelism, "parallelism", 0, "max number of parallel operations (0 = number of CPUs)")
+	cmd.Flags().StringVar(&cacheDir, "cache-dir", "", "path to build cache directory")
+	cmd.Flags().BoolVar(&force, "force", false, "ignore the build cache for the initial build")
+	cmd.Flags().BoolVar(&offline, "offline", false, "serve remote fetch sources only from the fetch cache")
+	fetchOpts.register(cmd)
+	cmd.Flags().StringVar(&addr, "addr", "localhost:8080", "address to listen on")
+	cmd.Flags().DurationVar(&pollInterval, "poll", 500*time.Millisecond, "interval for checking file changes")
+
+	return cmd
+}
+
+// fetchFlags are the fetcher settings shared by build and serve. Unset flags
+// fall back to the fetcher section of site.yaml.
+type fetchFlags struct {
+	timeout       time.Duration
+	retries       optionalIntFlag
+	backoff       time.Duration
+	retryStatuses []int
+	maxBodySize   byteSizeFlag
+}
+
+func (f *fetchFlags) register(cmd *cobra.Command) {
+	cmd.Flags().DurationVar(&f.timeout, "fetch-timeout", 0, "timeout for each HTTP fetch request (0 = site.yaml or none)")
+	cmd.Flags().Var(&f.retries, "fetch-retries", "number of retries for failed HTTP fetches, 0 to disable (default site.yaml or none)")
+	cmd.Flags().DurationVar(&f.backoff, "fetch-backoff", 0, "initial delay between fetch retries, doubled each time (0 = site.yaml or 1s)")
+	cmd.Flags().IntSliceVar(&f.retryStatuses, "fetch-retry-status", nil, "HTTP statuses to retry (default 429,500,502,503,504)")
+	cmd.Flags().Var(&f.maxBodySize, "fetch-max-body-size", "max size of a fetched body, e.g. 10MB (default site.yaml or 100MB)")
+}
+
+// optionalIntFlag is a non-negative integer flag that remembers whether it
+// was given, so that an explicit 0 can override site.yaml.
+type optionalIntFlag struct {
+	n   int
+	set bool
+}
+
+func (o *optionalIntFlag) String() string {
+	if !o.set {
+		return ""
+	}
+
+	return strconv.Itoa(o.n)
+}
+
+func (o *optionalIntFlag) Set(s string) error {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return fmt.Errorf("parse int: %w", err)
+	}
+
+	if n < 0 {
+		return fmt.Errorf("%w: %d", errNegativeFlag, n)
+	}
+
+	o.n, o.set = n, true
+
+	return nil
+}
+
+func (o *optionalIntFlag) Type() string {
+	return "int"
+}
+
+// value returns nil when the flag was not given.
+func (o *optionalIntFlag) value() *int {
+	if !o.set {
+		return nil
+	}
+
+	return &o.n
+}
+
+// byteSizeFlag is a flag value such as "10MB".
+type byteSizeFlag ssssg.ByteSize
+
+func (b *byteSizeFlag) String() string {
+	if *b == 0 {
+		return ""
+	}
+
+	return strconv.FormatInt(int64(*b), 10)
+}
+
+func (b *byteSizeFlag) Set(s string) error {
+	v, err := ssssg.ParseByteSize(s)
+	if err != nil {
+		return fmt.Errorf("parse size: %w", err)
+	}
+
+	*b = byteSizeFlag(v)
+
+	return nil
+}
+
+func (b *byteSizeFlag) Type() string {
+	return "size"
+}
+
 func newInitCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "init [directory]",
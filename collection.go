@@ -0,0 +1,127 @@
+package ssssg
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"text/template"
+)
+
+var (
+	errCollectionNotList    = errors.New("collection items must be a list")
+	errCollectionItemNotMap = errors.New("collection item must be a mapping")
+	errDuplicateOutput      = errors.New("duplicate output path")
+)
+
+// parseOutputPattern parses a collection output pattern. Missing keys are an
+// error so that a typo does not silently collapse every item onto one path.
+func parseOutputPattern(pattern string) (*template.Template, error) {
+	tmpl, err := template.New("output").Option("missingkey=error").Parse(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("parse output pattern %q: %w", pattern, err)
+	}
+
+	return tmpl, nil
+}
+
+// collectionItems returns the items of a collection, fetching and decoding
+// its source if it has one.
+func collectionItems(ctx context.Context, fetcher *Fetcher, c CollectionConfig) ([]any, error) {
+	if c.Source == nil {
+		return c.Data, nil
+	}
+
+	entry := *c.Source
+	if entry.Format == "" {
+		entry.Format = FormatAuto
+	}
+
+	v, err := fetcher.Resolve(ctx, entry)
+	if err != nil {
+		return nil, err
+	}
+
+	items, ok := v.([]any)
+	if !ok {
+		return nil, fmt.Errorf("%s: %w, got %T", entry.describe(), errCollectionNotList, v)
+	}
+
+	return items, nil
+}
+
+// expandCollection generates one page per item. The item's fields become the
+// page data and the output path is rendered from the collection's pattern.
+func expandCollection(c CollectionConfig, items []any) ([]PageConfig, error) {
+	outputTmpl, err := parseOutputPattern(c.Output)
+	if err != nil {
+		return nil, err
+	}
+
+	pages := make([]PageConfig, 0, len(items))
+
+	for i, item := range items {
+		fields, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("item %d: %w, got %T", i, errCollectionItemNotMap, item)
+		}
+
+		var buf bytes.Buffer
+		if err := outputTmpl.Execute(&buf, fields); err != nil {
+			return nil, fmt.Errorf("item %d: render output: %w", i, err)
+		}
+
+		output := buf.String()
+		if output == "" {
+			return nil, fmt.Errorf("item %d: %w", i, errOutputRequired)
+		}
+
+		if err := validateOutput(output); err != nil {
+			return nil, fmt.Errorf("item %d: %w", i, err)
+		}
+
+		pages = append(pages, PageConfig{
+			Template: c.Template,
+			Output:   output,
+			Layout:   c.Layout,
+			Data:     maps.Clone(fields),
+			Sitemap:  c.Sitemap,
+		})
+	}
+
+	return pages, nil
+}
+
+// collectionName returns the configured name or a positional fallback for
+// log messages.
+func collectionName(c CollectionConfig, i int) string {
+	if c.Name != "" {
+		return c.Name
+	}
+
+	return fmt.Sprintf("collections[%d]", i)
+}
+
+// plannedOutput is a file the build writes and what writes it.
+type plannedOutput struct {
+	path  string
+	owner string
+}
+
+// checkDuplicateOutputs rejects two writers of the same output file.
+func checkDuplicateOutputs(outputs []plannedOutput) error {
+	seen := make(map[string]string, len(outputs))
+
+	for _, out := range outputs {
+		output := filepath.ToSlash(filepath.Clean(out.path))
+		if owner, ok := seen[output]; ok {
+			return fmt.Errorf("%w: %s (%s and %s)", errDuplicateOutput, out.path, owner, out.owner)
+		}
+
+		seen[output] = out.owner
+	}
+
+	return nil
+}
@@ -0,0 +1,216 @@
+package ssssg
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExpandCollection(t *testing.T) {
+	t.Parallel()
+
+	c := CollectionConfig{
+		Template: "product.html",
+		Output:   "products/{{ .slug }}/index.html",
+		Layout:   "_layout.html",
+	}
+
+	items := []any{
+		map[string]any{"slug": "apple", "name": "Apple"},
+		map[string]any{"slug": "banana", "name": "Banana"},
+	}
+
+	pages, err := expandCollection(c, items)
+	if err != nil {
+		t.Fatalf("expandCollection failed: %v", err)
+	}
+
+	if len(pages) != 2 {
+		t.Fatalf("len(pages) = %d, want 2", len(pages))
+	}
+
+	if pages[1].Output != "products/banana/index.html" {
+		t.Errorf("output = %q", pages[1].Output)
+	}
+
+	if pages[1].Template != "product.html" || pages[1].Layout != "_layout.html" {
+		t.Errorf("page = %+v", pages[1])
+	}
+
+	if pages[1].Data["name"] != "Banana" {
+		t.Errorf("data = %v", pages[1].Data)
+	}
+}
+
+func TestExpandCollection_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		output string
+		items  []any
+		want   error
+	}{
+		{
+			name:   "item not a map",
+			output: "{{ . }}.html",
+			items:  []any{"plain"},
+			want:   errCollectionItemNotMap,
+		},
+		{
+			name:   "path traversal",
+			output: "{{ .slug }}/index.html",
+			items:  []any{map[string]any{"slug": "../.."}},
+			want:   errOutputPathTraversal,
+		},
+		{
+			name:   "empty output",
+			output: "{{ .slug }}",
+			items:  []any{map[string]any{"slug": ""}},
+			want:   errOutputRequired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := expandCollection(CollectionConfig{Template: "t.html", Output: tt.output}, tt.items)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	// Missing keys are reported instead of rendering "<no value>"
+	_, err := expandCollection(CollectionConfig{Template: "t.html", Output: "{{ .slug }}.html"}, []any{map[string]any{"name": "x"}})
+	if err == nil {
+		t.Error("expected error for missing key in output pattern")
+	}
+}
+
+func TestCheckDuplicateOutputs(t *testing.T) {
+	t.Parallel()
+
+	outputs := []plannedOutput{{path: "a/index.html", owner: "page a"}, {path: "./a/index.html", owner: "page b"}}
+	if err := checkDuplicateOutputs(outputs); !errors.Is(err, errDuplicateOutput) {
+		t.Errorf("err = %v, want errDuplicateOutput", err)
+	}
+}
+
+func TestBuild_WithCollections(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[{"id": "1", "title": "First"}, {"id": "2", "title": "Second"}]`))
+	}))
+	defer srv.Close()
+
+	yaml := `
+global:
+  data:
+    site_name: "Shop"
+
+pages:
+  - template: "index.html"
+    output: "index.html"
+
+collections:
+  - name: products
+    template: "product.html"
+    output: "products/{{ .slug }}/index.html"
+    data:
+      - slug: "apple"
+        name: "Apple"
+      - slug: "banana"
+        name: "Banana"
+  - name: posts
+    template: "post.html"
+    output: "posts/{{ .id }}.html"
+    source:
+      url: "` + srv.URL + `"
+  - name: team
+    template: "member.html"
+    output: "team/{{ .name }}.html"
+    source: "team.yaml"
+`
+
+	dir := setupProject(t, yaml)
+
+	team := "- name: alice\n  role: dev\n- name: bob\n  role: ops\n"
+	if err := os.WriteFile(filepath.Join(dir, "team.yaml"), []byte(team), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	templates := map[string]string{
+		"index.html":   `home`,
+		"product.html": `<h1>{{ .Page.name }}</h1><p>{{ .Global.site_name }}</p>`,
+		"post.html":    `<h1>{{ .Page.title }}</h1>`,
+		"member.html":  `<h1>{{ .Page.name }} ({{ .Page.role }})</h1>`,
+	}
+	for name, content := range templates {
+		if err := os.WriteFile(filepath.Join(dir, "templates", name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := Build(t.Context(), BuildOptions{
+		ConfigPath: filepath.Join(dir, "site.yaml"),
+		Timeout:    10 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	want := map[string]string{
+		"products/apple/index.html":  "<h1>Apple</h1><p>Shop</p>",
+		"products/banana/index.html": "<h1>Banana</h1><p>Shop</p>",
+		"posts/2.html":               "<h1>Second</h1>",
+		"team/bob.html":              "<h1>bob (ops)</h1>",
+	}
+	for output, expected := range want {
+		content, err := os.ReadFile(filepath.Join(dir, "public", output))
+		if err != nil {
+			t.Fatalf("%s not generated: %v", output, err)
+		}
+
+		if string(content) != expected {
+			t.Errorf("%s = %q, want %q", output, content, expected)
+		}
+	}
+}
+
+func TestBuild_CollectionSourceNotList(t *testing.T) {
+	t.Parallel()
+
+	yaml := `
+collections:
+  - template: "item.html"
+    output: "{{ .slug }}.html"
+    source: "items.json"
+`
+
+	dir := setupProject(t, yaml)
+
+	if err := os.WriteFile(filepath.Join(dir, "items.json"), []byte(`{"slug": "x"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := Build(t.Context(), BuildOptions{
+		ConfigPath: filepath.Join(dir, "site.yaml"),
+		Timeout:    10 * time.Second,
+	})
+	if !errors.Is(err, errCollectionNotList) {
+		t.Errorf("err = %v, want errCollectionNotList", err)
+	}
+
+	if err != nil && !strings.Contains(err.Error(), "collections[0]") {
+		t.Errorf("error should name the collection: %v", err)
+	}
+}
@@ -3,17 +3,80 @@ package ssssg
 import (
 	"errors"
 	"fmt"
+	"html/template"
+	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/goccy/go-yaml"
 )
 
 type Config struct {
-	Global GlobalConfig `yaml:"global"`
-	Pages  []PageConfig `yaml:"pages"`
-	Static StaticConfig `yaml:"static"`
+	Global      GlobalConfig       `yaml:"global"`
+	Pages       []PageConfig       `yaml:"pages"`
+	Collections []CollectionConfig `yaml:"collections"`
+	Content     ContentConfig      `yaml:"content"`
+	Static      StaticConfig       `yaml:"static"`
+	Sitemap     *SitemapConfig     `yaml:"sitemap"`
+	Feeds       []FeedConfig       `yaml:"feeds"`
+	Fetcher     FetcherConfig      `yaml:"fetcher"`
+}
+
+// FetcherConfig tunes remote fetches. Timeout bounds each HTTP request
+// separately from the build timeout. Failed requests are retried Retries
+// times with exponential backoff starting at Backoff.
+type FetcherConfig struct {
+	Timeout       time.Duration `yaml:"timeout"`
+	Retries       int           `yaml:"retries"`
+	Backoff       time.Duration `yaml:"backoff"`
+	RetryStatuses []int         `yaml:"retry_statuses"`
+	MaxBodySize   ByteSize      `yaml:"max_body_size"` // per response, default 100MB
+}
+
+// SitemapConfig enables sitemap.xml generation. The URLs are built from
+// global.base_url and the outputs of every rendered HTML page. ChangeFreq and
+// Priority are defaults that pages can override.
+type SitemapConfig struct {
+	Output     string   `yaml:"output"` // defaults to sitemap.xml
+	ChangeFreq string   `yaml:"changefreq"`
+	Priority   *float64 `yaml:"priority"`
+}
+
+// PageSitemapConfig overrides the sitemap defaults for one page.
+type PageSitemapConfig struct {
+	ChangeFreq string   `yaml:"changefreq"`
+	Priority   *float64 `yaml:"priority"`
+	Exclude    bool     `yaml:"exclude"`
+}
+
+// FeedConfig writes an RSS 2.0 and/or Atom feed of selected pages. Pages are
+// selected by output glob, section (first directory of the output), tag (an
+// entry of the "tags" page data) or collection; all given selectors must
+// match. Fields maps feed fields (title, date, summary, content) to page data
+// keys.
+type FeedConfig struct {
+	Name        string            `yaml:"name"`
+	Title       string            `yaml:"title"`
+	Description string            `yaml:"description"`
+	Author      string            `yaml:"author"` // Atom feed author, the title if empty
+	Pages       string            `yaml:"pages"`
+	Section     string            `yaml:"section"`
+	Tag         string            `yaml:"tag"`
+	Collection  string            `yaml:"collection"`
+	Fields      map[string]string `yaml:"fields"`
+	Limit       int               `yaml:"limit"`
+	RSS         string            `yaml:"rss"`  // RSS output path
+	Atom        string            `yaml:"atom"` // Atom output path
+}
+
+// ContentConfig sets defaults for Markdown pages in the content directory.
+// Front matter can override both per file.
+type ContentConfig struct {
+	Template string `yaml:"template"`
+	Layout   string `yaml:"layout"`
 }
 
 type StaticConfig struct {
@@ -23,29 +86,157 @@ type StaticConfig struct {
 type PipelineConfig struct {
 	Match    string   `yaml:"match"`
 	Commands []string `yaml:"commands"`
+	Always   bool     `yaml:"always"` // run on every build, for commands reading other inputs
 }
 
 type GlobalConfig struct {
-	Layout string            `yaml:"layout"`
-	Data   map[string]any    `yaml:"data"`
-	Fetch  map[string]string `yaml:"fetch"`
+	Layout  string                `yaml:"layout"`
+	BaseURL string                `yaml:"base_url"` // absolute site URL, e.g. https://example.com
+	Data    map[string]any        `yaml:"data"`
+	Fetch   map[string]FetchEntry `yaml:"fetch"`
 }
 
 type PageConfig struct {
-	Template string            `yaml:"template"`
-	Output   string            `yaml:"output"`
-	Layout   string            `yaml:"layout"`
-	Data     map[string]any    `yaml:"data"`
-	Fetch    map[string]string `yaml:"fetch"`
+	Template string                `yaml:"template"`
+	Output   string                `yaml:"output"`
+	Layout   string                `yaml:"layout"`
+	Data     map[string]any        `yaml:"data"`
+	Fetch    map[string]FetchEntry `yaml:"fetch"`
+	Paginate *PaginateConfig       `yaml:"paginate"`
+	Sitemap  *PageSitemapConfig    `yaml:"sitemap"`
+
+	content template.HTML // rendered Markdown body of content pages
+}
+
+// PaginateConfig splits a list over several outputs. The list is either a
+// key of the page data (inline or fetched) or the pages generated by a
+// collection. Page 1 is written to the page's output, page N to
+// <output dir>/page/N/index.html.
+type PaginateConfig struct {
+	Items      string `yaml:"items"`
+	Collection string `yaml:"collection"`
+	PerPage    int    `yaml:"per_page"`
+}
+
+// CollectionConfig generates one page per item of a list. Items come from
+// inline data or from a source (local file or URL) whose format defaults to
+// auto. Output is a text/template evaluated with each item, and the item's
+// fields become the page data.
+type CollectionConfig struct {
+	Name     string             `yaml:"name"`
+	Template string             `yaml:"template"`
+	Output   string             `yaml:"output"`
+	Layout   string             `yaml:"layout"`
+	Data     []any              `yaml:"data"`
+	Source   *FetchEntry        `yaml:"source"`
+	Sitemap  *PageSitemapConfig `yaml:"sitemap"` // applies to every generated page
+}
+
+// FetchEntry is a single fetch source. In site.yaml it is either a plain
+// string (URL or local path) or a mapping with options:
+//
+//	fetch:
+//	  css: "static/style.css"
+//	  projects:
+//	    url: "https://api.example.com/projects.json"
+//	    format: "json"
+//	    headers:
+//	      Authorization: "Bearer ${API_TOKEN}"
+//
+// Method, Headers, Body and Query only apply to URLs. Their values may
+// reference environment variables. Type "graphql" posts GraphQL (inline) or
+// GraphQLFile with Variables to the URL and yields the response's data. Exec
+// replaces the URL with a command run in the site directory. A "glob:"
+// pattern or a directory yields all matching files. Fallback sources are
+// tried when the entry fails; an Optional entry, or one with a Default,
+// then yields the default instead of failing the build. Select keeps only
+// the HTML elements matching a CSS selector. Content is converted to UTF-8
+// from Encoding or the charset declared by the response or document. A
+// Download entry saves a remote file into the output directory and yields
+// its StaticFileInfo instead of the content.
+type FetchEntry struct {
+	URL         string            `yaml:"url"`
+	Format      string            `yaml:"format"` // raw (default), auto, json, yaml, csv, toml, feed
+	TTL         time.Duration     `yaml:"ttl"`    // reuse the cached response of a URL for this long
+	Method      string            `yaml:"method"` // defaults to GET, or POST with a body
+	Headers     map[string]string `yaml:"headers"`
+	Body        string            `yaml:"body"`
+	Query       map[string]string `yaml:"query"` // added to the URL's query string
+	Type        string            `yaml:"type"`  // "graphql", or empty for a plain request
+	GraphQL     string            `yaml:"graphql"`
+	GraphQLFile string            `yaml:"graphql_file"` // relative to site.yaml
+	Variables   map[string]any    `yaml:"variables"`
+	Follow      *FollowConfig     `yaml:"follow"` // follow a paginated API, yielding all items
+	Exec        *ExecCommand      `yaml:"exec"`   // use a command's stdout instead of a URL
+	As          string            `yaml:"as"`     // map (default) or list, for glob and directory sources
+	Optional    bool              `yaml:"optional"`
+	Default     any               `yaml:"default"`       // value of an optional entry whose sources all failed
+	Fallback    []FetchEntry      `yaml:"fallback"`      // sources tried in order when the entry fails
+	Select      string            `yaml:"select"`        // CSS selector of the HTML elements to keep
+	Extract     string            `yaml:"extract"`       // outer (default), inner or text
+	Encoding    string            `yaml:"encoding"`      // charset of the content, detected if empty
+	Download    string            `yaml:"download"`      // save the file at this path under the output directory
+	MaxBodySize ByteSize          `yaml:"max_body_size"` // overrides the fetcher's limit
+
+	literalBody bool // send Body without expanding environment variables
+}
+
+func (e *FetchEntry) UnmarshalYAML(unmarshal func(any) error) error {
+	var source string
+	if err := unmarshal(&source); err == nil {
+		*e = FetchEntry{URL: source}
+
+		return nil
+	}
+
+	type plain FetchEntry
+
+	var p plain
+	if err := unmarshal(&p); err != nil {
+		return err
+	}
+
+	*e = FetchEntry(p)
+
+	if e.Follow != nil && e.Follow.disabled {
+		e.Follow = nil
+	}
+
+	return nil
 }
 
 var (
-	errTemplateRequired     = errors.New("template is required")
-	errOutputRequired       = errors.New("output is required")
-	errOutputPathTraversal  = errors.New("output path must not escape output directory")
-	errPipelineMatchEmpty   = errors.New("pipeline match pattern is required")
-	errPipelineNoCommands   = errors.New("pipeline must have at least one command")
-	errPipelineInvalidMatch = errors.New("pipeline match pattern is invalid")
+	errTemplateRequired        = errors.New("template is required")
+	errOutputRequired          = errors.New("output is required")
+	errOutputPathTraversal     = errors.New("output path must not escape output directory")
+	errPipelineMatchEmpty      = errors.New("pipeline match pattern is required")
+	errPipelineNoCommands      = errors.New("pipeline must have at least one command")
+	errPipelineInvalidMatch    = errors.New("pipeline match pattern is invalid")
+	errFetchURLRequired        = errors.New("fetch url is required")
+	errCollectionNoItems       = errors.New("collection requires data or source")
+	errCollectionBothItems     = errors.New("collection must not have both data and source")
+	errCollectionOutput        = errors.New("collection output pattern is invalid")
+	errCollectionNameDuplicate = errors.New("duplicate collection name")
+	errPaginateSource          = errors.New("paginate requires exactly one of items or collection")
+	errPaginatePerPage         = errors.New("paginate per_page must be positive")
+	errCollectionUnknown       = errors.New("unknown collection")
+	errFetchFormatInvalid      = errors.New("fetch format is invalid")
+	errBaseURLRequired         = errors.New("global.base_url is required")
+	errBaseURLInvalid          = errors.New("global.base_url must be an absolute http(s) URL")
+	errChangeFreqInvalid       = errors.New("sitemap changefreq is invalid")
+	errPriorityRange           = errors.New("sitemap priority must be between 0.0 and 1.0")
+	errFeedNameRequired        = errors.New("feed name is required")
+	errFeedNameDuplicate       = errors.New("duplicate feed name")
+	errFeedNoOutput            = errors.New("feed requires rss or atom output")
+	errFeedNoSelector          = errors.New("feed requires pages, section, tag or collection")
+	errFeedPagesInvalid        = errors.New("feed pages pattern is invalid")
+	errFeedFieldUnknown        = errors.New("unknown feed field")
+	errFetchMaxBodyNegative    = errors.New("fetch max_body_size must not be negative")
+	errFetchTTLNegative        = errors.New("fetch ttl must not be negative")
+	errFetcherNegative         = errors.New("fetcher timeout, retries and backoff must not be negative")
+	errRetryStatusInvalid      = errors.New("fetcher retry status is not an HTTP error status")
+	errFetchMethodInvalid      = errors.New("fetch method is invalid")
+	errFetchOptionsLocal       = errors.New("fetch method, headers, body and query require an http(s) url")
 )
 
 func LoadConfig(path string) (*Config, error) {
@@ -59,7 +250,15 @@ func LoadConfig(path string) (*Config, error) {
 		return nil, fmt.Errorf("parse config file: %w", err)
 	}
 
+	if err := validateFetchMap("global.fetch", cfg.Global.Fetch); err != nil {
+		return nil, err
+	}
+
 	for i, p := range cfg.Pages {
+		if err := validateFetchMap(fmt.Sprintf("pages[%d].fetch", i), p.Fetch); err != nil {
+			return nil, err
+		}
+
 		if p.Template == "" {
 			return nil, fmt.Errorf("pages[%d]: %w", i, errTemplateRequired)
 		}
@@ -68,10 +267,65 @@ func LoadConfig(path string) (*Config, error) {
 			return nil, fmt.Errorf("pages[%d]: %w", i, errOutputRequired)
 		}
 
-		cleaned := filepath.Clean(p.Output)
-		if filepath.IsAbs(cleaned) || strings.HasPrefix(cleaned, "..") {
-			return nil, fmt.Errorf("pages[%d]: %w: %s", i, errOutputPathTraversal, p.Output)
+		if err := validateOutput(p.Output); err != nil {
+			return nil, fmt.Errorf("pages[%d]: %w", i, err)
+		}
+
+		if err := validatePageSitemap(p.Sitemap); err != nil {
+			return nil, fmt.Errorf("pages[%d].sitemap: %w", i, err)
+		}
+	}
+
+	collectionNames := make(map[string]struct{}, len(cfg.Collections))
+
+	for i, c := range cfg.Collections {
+		if err := validateCollection(c); err != nil {
+			return nil, fmt.Errorf("collections[%d]: %w", i, err)
+		}
+
+		if c.Name == "" {
+			continue
+		}
+
+		if _, ok := collectionNames[c.Name]; ok {
+			return nil, fmt.Errorf("collections[%d]: %w: %s", i, errCollectionNameDuplicate, c.Name)
+		}
+
+		collectionNames[c.Name] = struct{}{}
+	}
+
+	for i, p := range cfg.Pages {
+		if p.Paginate == nil {
+			continue
+		}
+
+		if err := validatePaginate(p.Paginate, collectionNames); err != nil {
+			return nil, fmt.Errorf("pages[%d].paginate: %w", i, err)
+		}
+	}
+
+	if err := validateFetcher(cfg.Fetcher); err != nil {
+		return nil, fmt.Errorf("fetcher: %w", err)
+	}
+
+	if cfg.Sitemap != nil {
+		if err := validateSitemap(cfg.Sitemap, cfg.Global.BaseURL); err != nil {
+			return nil, fmt.Errorf("sitemap: %w", err)
+		}
+	}
+
+	feedNames := make(map[string]struct{}, len(cfg.Feeds))
+
+	for i, f := range cfg.Feeds {
+		if err := validateFeed(f, cfg.Global.BaseURL, collectionNames); err != nil {
+			return nil, fmt.Errorf("feeds[%d]: %w", i, err)
+		}
+
+		if _, ok := feedNames[f.Name]; ok {
+			return nil, fmt.Errorf("feeds[%d]: %w: %s", i, errFeedNameDuplicate, f.Name)
 		}
+
+		feedNames[f.Name] = struct{}{}
 	}
 
 	for i, p := range cfg.Static.Pipelines {
@@ -90,3 +344,244 @@ func LoadConfig(path string) (*Config, error) {
 
 	return &cfg, nil
 }
+
+// validateOutput rejects output paths that would be written outside the
+// output directory.
+func validateOutput(output string) error {
+	cleaned := filepath.Clean(output)
+	if filepath.IsAbs(cleaned) || strings.HasPrefix(cleaned, "..") {
+		return fmt.Errorf("%w: %s", errOutputPathTraversal, output)
+	}
+
+	return nil
+}
+
+func validateCollection(c CollectionConfig) error {
+	if c.Template == "" {
+		return errTemplateRequired
+	}
+
+	if c.Output == "" {
+		return errOutputRequired
+	}
+
+	if _, err := parseOutputPattern(c.Output); err != nil {
+		return fmt.Errorf("%w: %w", errCollectionOutput, err)
+	}
+
+	switch {
+	case c.Data == nil && c.Source == nil:
+		return errCollectionNoItems
+	case c.Data != nil && c.Source != nil:
+		return errCollectionBothItems
+	case c.Source != nil:
+		if err := validateFetchEntry(*c.Source); err != nil {
+			return fmt.Errorf("source: %w", err)
+		}
+	}
+
+	if err := validatePageSitemap(c.Sitemap); err != nil {
+		return fmt.Errorf("sitemap: %w", err)
+	}
+
+	return nil
+}
+
+func validatePaginate(p *PaginateConfig, collections map[string]struct{}) error {
+	if (p.Items == "") == (p.Collection == "") {
+		return errPaginateSource
+	}
+
+	if p.PerPage <= 0 {
+		return errPaginatePerPage
+	}
+
+	if p.Collection != "" {
+		if _, ok := collections[p.Collection]; !ok {
+			return fmt.Errorf("%w: %s", errCollectionUnknown, p.Collection)
+		}
+	}
+
+	return nil
+}
+
+func validateFetchMap(path string, fetchMap map[string]FetchEntry) error {
+	for key, entry := range fetchMap {
+		if err := validateFetchEntry(entry); err != nil {
+			return fmt.Errorf("%s.%s: %w", path, key, err)
+		}
+	}
+
+	return nil
+}
+
+func validateFetchEntry(entry FetchEntry) error {
+	if entry.Exec != nil {
+		if err := validateExec(entry); err != nil {
+			return err
+		}
+	} else if entry.URL == "" {
+		return errFetchURLRequired
+	}
+
+	if !isValidFormat(entry.Format) {
+		return fmt.Errorf("%w: %s", errFetchFormatInvalid, entry.Format)
+	}
+
+	if entry.TTL < 0 {
+		return errFetchTTLNegative
+	}
+
+	if entry.MaxBodySize < 0 {
+		return errFetchMaxBodyNegative
+	}
+
+	if err := validateGlob(entry); err != nil {
+		return err
+	}
+
+	if err := validateSelect(entry); err != nil {
+		return err
+	}
+
+	if err := validateEncoding(entry); err != nil {
+		return err
+	}
+
+	if err := validateDownload(entry); err != nil {
+		return err
+	}
+
+	for i, fallback := range entry.Fallback {
+		if err := validateFetchEntry(fallback); err != nil {
+			return fmt.Errorf("fallback[%d]: %w", i, err)
+		}
+	}
+
+	switch entry.Type {
+	case "":
+	case FetchTypeGraphQL:
+		if err := validateGraphQL(entry); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("%w: %s", errFetchTypeUnknown, entry.Type)
+	}
+
+	if entry.Follow != nil {
+		if err := validateFollow(entry); err != nil {
+			return err
+		}
+	}
+
+	if entry.hasRequestOptions() && !isRemote(entry.URL) {
+		return errFetchOptionsLocal
+	}
+
+	if entry.Method != "" && !slices.Contains(validMethods, strings.ToUpper(entry.Method)) {
+		return fmt.Errorf("%w: %s", errFetchMethodInvalid, entry.Method)
+	}
+
+	return nil
+}
+
+func validateBaseURL(baseURL string) error {
+	if baseURL == "" {
+		return errBaseURLRequired
+	}
+
+	u, err := url.Parse(baseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: %s", errBaseURLInvalid, baseURL)
+	}
+
+	return nil
+}
+
+func validateSitemap(s *SitemapConfig, baseURL string) error {
+	if err := validateBaseURL(baseURL); err != nil {
+		return err
+	}
+
+	if err := validateOutput(s.Output); err != nil {
+		return err
+	}
+
+	return validateSitemapEntry(s.ChangeFreq, s.Priority)
+}
+
+func validatePageSitemap(s *PageSitemapConfig) error {
+	if s == nil {
+		return nil
+	}
+
+	return validateSitemapEntry(s.ChangeFreq, s.Priority)
+}
+
+func validateSitemapEntry(changeFreq string, priority *float64) error {
+	if changeFreq != "" && !isValidChangeFreq(changeFreq) {
+		return fmt.Errorf("%w: %s", errChangeFreqInvalid, changeFreq)
+	}
+
+	if priority != nil && (*priority < 0 || *priority > 1) {
+		return fmt.Errorf("%w, got %v", errPriorityRange, *priority)
+	}
+
+	return nil
+}
+
+func validateFeed(f FeedConfig, baseURL string, collections map[string]struct{}) error {
+	if f.Name == "" {
+		return errFeedNameRequired
+	}
+
+	if err := validateBaseURL(baseURL); err != nil {
+		return err
+	}
+
+	if f.RSS == "" && f.Atom == "" {
+		return errFeedNoOutput
+	}
+
+	for _, output := range []string{f.RSS, f.Atom} {
+		if err := validateOutput(output); err != nil {
+			return err
+		}
+	}
+
+	if f.Pages == "" && f.Section == "" && f.Tag == "" && f.Collection == "" {
+		return errFeedNoSelector
+	}
+
+	if _, err := filepath.Match(f.Pages, ""); err != nil {
+		return fmt.Errorf("%w: %s", errFeedPagesInvalid, f.Pages)
+	}
+
+	if f.Collection != "" {
+		if _, ok := collections[f.Collection]; !ok {
+			return fmt.Errorf("%w: %s", errCollectionUnknown, f.Collection)
+		}
+	}
+
+	for field := range f.Fields {
+		if _, ok := defaultFeedFields[field]; !ok {
+			return fmt.Errorf("%w: %s", errFeedFieldUnknown, field)
+		}
+	}
+
+	return nil
+}
+
+func validateFetcher(c FetcherConfig) error {
+	if c.Timeout < 0 || c.Retries < 0 || c.Backoff < 0 || c.MaxBodySize < 0 {
+		return errFetcherNegative
+	}
+
+	for _, status := range c.RetryStatuses {
+		if status < 400 || status > 599 {
+			return fmt.Errorf("%w: %d", errRetryStatusInvalid, status)
+		}
+	}
+
+	return nil
+}
@@ -4,7 +4,9 @@ import (
 	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestLoadConfig(t *testing.T) {
@@ -51,7 +53,7 @@ pages:
 		t.Errorf("global.data.site_name = %v, want %q", cfg.Global.Data["site_name"], "Test Site")
 	}
 
-	if cfg.Global.Fetch["reset_css"] != "https://example.com/reset.css" {
+	if cfg.Global.Fetch["reset_css"].URL != "https://example.com/reset.css" {
 		t.Errorf("global.fetch.reset_css = %v", cfg.Global.Fetch["reset_css"])
 	}
 
@@ -73,7 +75,7 @@ pages:
 		t.Errorf("pages[1].layout = %q", p1.Layout)
 	}
 
-	if p1.Fetch["bio"] != "data/bio.txt" {
+	if p1.Fetch["bio"].URL != "data/bio.txt" {
 		t.Errorf("pages[1].fetch.bio = %v", p1.Fetch["bio"])
 	}
 }
@@ -313,3 +315,336 @@ pages:
 		t.Errorf("expected errOutputPathTraversal, got: %v", err)
 	}
 }
+
+func loadTestConfig(t *testing.T, yaml string) (*Config, error) {
+	t.Helper()
+
+	cfgPath := filepath.Join(t.TempDir(), "site.yaml")
+	if err := os.WriteFile(cfgPath, []byte(yaml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	return LoadConfig(cfgPath)
+}
+
+func TestLoadConfig_FetchEntryForms(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := loadTestConfig(t, `
+global:
+  fetch:
+    css: "static/style.css"
+    projects:
+      url: "https://api.example.com/projects.json"
+      format: "json"
+`)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	if got := cfg.Global.Fetch["css"]; got.URL != "static/style.css" || got.Format != "" {
+		t.Errorf("css = %+v", got)
+	}
+
+	if got := cfg.Global.Fetch["projects"]; got.URL != "https://api.example.com/projects.json" || got.Format != FormatJSON {
+		t.Errorf("projects = %+v", got)
+	}
+}
+
+func TestLoadConfig_FetchEntryInvalid(t *testing.T) {
+	t.Parallel()
+
+	_, err := loadTestConfig(t, `
+pages:
+  - template: "index.html"
+    output: "index.html"
+    fetch:
+      items:
+        url: "items.xml"
+        format: "xml"
+`)
+	if !errors.Is(err, errFetchFormatInvalid) {
+		t.Errorf("expected errFetchFormatInvalid, got: %v", err)
+	}
+
+	_, err = loadTestConfig(t, `
+global:
+  fetch:
+    items:
+      format: "json"
+`)
+	if !errors.Is(err, errFetchURLRequired) {
+		t.Errorf("expected errFetchURLRequired, got: %v", err)
+	}
+
+	_, err = loadTestConfig(t, `
+global:
+  fetch:
+    items:
+      url: "data/items.json"
+      headers:
+        Accept: "application/json"
+`)
+	if !errors.Is(err, errFetchOptionsLocal) {
+		t.Errorf("expected errFetchOptionsLocal, got: %v", err)
+	}
+
+	_, err = loadTestConfig(t, `
+global:
+  fetch:
+    items:
+      url: "https://example.com/items"
+      method: "FETCH"
+`)
+	if !errors.Is(err, errFetchMethodInvalid) {
+		t.Errorf("expected errFetchMethodInvalid, got: %v", err)
+	}
+
+	_, err = loadTestConfig(t, `
+global:
+  fetch:
+    log:
+      url: "https://example.com/log"
+      exec: "git log"
+`)
+	if !errors.Is(err, errExecWithURL) {
+		t.Errorf("expected errExecWithURL, got: %v", err)
+	}
+
+	_, err = loadTestConfig(t, `
+global:
+  fetch:
+    log:
+      exec: []
+`)
+	if !errors.Is(err, errExecEmpty) {
+		t.Errorf("expected errExecEmpty, got: %v", err)
+	}
+
+	_, err = loadTestConfig(t, `
+global:
+  fetch:
+    log:
+      exec: "git log"
+      method: "POST"
+`)
+	if !errors.Is(err, errExecOptions) {
+		t.Errorf("expected errExecOptions, got: %v", err)
+	}
+
+	_, err = loadTestConfig(t, `
+global:
+  fetch:
+    widget:
+      url: "https://example.com/widget"
+      fallback:
+        - "data/widget.json"
+        - url: "https://mirror.example.com/widget"
+          format: "xml"
+`)
+	if !errors.Is(err, errFetchFormatInvalid) || !strings.Contains(err.Error(), "fallback[1]") {
+		t.Errorf("expected errFetchFormatInvalid in fallback[1], got: %v", err)
+	}
+
+	_, err = loadTestConfig(t, `
+global:
+  fetch:
+    items:
+      url: "https://example.com/items"
+      max_body_size: -1
+`)
+	if !errors.Is(err, errFetchMaxBodyNegative) {
+		t.Errorf("expected errFetchMaxBodyNegative, got: %v", err)
+	}
+}
+
+func TestLoadConfig_Collections(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := loadTestConfig(t, `
+collections:
+  - name: products
+    template: "product.html"
+    output: "products/{{ .slug }}/index.html"
+    data:
+      - slug: "a"
+  - name: posts
+    template: "post.html"
+    output: "posts/{{ .id }}.html"
+    source: "posts.json"
+`)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	if len(cfg.Collections) != 2 {
+		t.Fatalf("len(collections) = %d, want 2", len(cfg.Collections))
+	}
+
+	if len(cfg.Collections[0].Data) != 1 {
+		t.Errorf("collections[0].data = %v", cfg.Collections[0].Data)
+	}
+
+	if src := cfg.Collections[1].Source; src == nil || src.URL != "posts.json" {
+		t.Errorf("collections[1].source = %+v", src)
+	}
+}
+
+func TestLoadConfig_CollectionInvalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		yaml string
+		want error
+	}{
+		{
+			name: "no items",
+			yaml: "collections:\n  - template: t.html\n    output: \"{{ .slug }}.html\"\n",
+			want: errCollectionNoItems,
+		},
+		{
+			name: "both data and source",
+			yaml: "collections:\n  - template: t.html\n    output: \"{{ .slug }}.html\"\n    data: []\n    source: items.json\n",
+			want: errCollectionBothItems,
+		},
+		{
+			name: "bad pattern",
+			yaml: "collections:\n  - template: t.html\n    output: \"{{ .slug \"\n    data: []\n",
+			want: errCollectionOutput,
+		},
+		{
+			name: "missing template",
+			yaml: "collections:\n  - output: \"{{ .slug }}.html\"\n    data: []\n",
+			want: errTemplateRequired,
+		},
+		{
+			name: "duplicate name",
+			yaml: "collections:\n  - name: posts\n    template: t.html\n    output: \"a/{{ .slug }}.html\"\n    data: []\n" +
+				"  - name: posts\n    template: t.html\n    output: \"b/{{ .slug }}.html\"\n    data: []\n",
+			want: errCollectionNameDuplicate,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := loadTestConfig(t, tt.yaml)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_PaginateInvalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		paginate string
+		want     error
+	}{
+		{"no source", "per_page: 10", errPaginateSource},
+		{"both sources", "items: posts\n      collection: posts\n      per_page: 10", errPaginateSource},
+		{"zero per_page", "items: posts", errPaginatePerPage},
+		{"unknown collection", "collection: nope\n      per_page: 10", errCollectionUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			yaml := "pages:\n  - template: list.html\n    output: index.html\n    paginate:\n      " + tt.paginate + "\n"
+
+			_, err := loadTestConfig(t, yaml)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_SitemapInvalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		yaml string
+		want error
+	}{
+		{"no base url", "sitemap: {}\n", errBaseURLRequired},
+		{"relative base url", "global:\n  base_url: /site\nsitemap: {}\n", errBaseURLInvalid},
+		{"bad changefreq", "global:\n  base_url: https://example.com\nsitemap:\n  changefreq: sometimes\n", errChangeFreqInvalid},
+		{"page priority", "pages:\n  - template: index.html\n    output: index.html\n    sitemap:\n      priority: 1.5\n", errPriorityRange},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := loadTestConfig(t, tt.yaml)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_FeedInvalid(t *testing.T) {
+	t.Parallel()
+
+	base := "global:\n  base_url: https://example.com\nfeeds:\n  - "
+
+	tests := []struct {
+		name string
+		yaml string
+		want error
+	}{
+		{"no name", base + "section: blog\n    rss: feed.xml\n", errFeedNameRequired},
+		{"no base url", "feeds:\n  - name: blog\n    section: blog\n    rss: feed.xml\n", errBaseURLRequired},
+		{"no output", base + "name: blog\n    section: blog\n", errFeedNoOutput},
+		{"no selector", base + "name: blog\n    rss: feed.xml\n", errFeedNoSelector},
+		{"unknown collection", base + "name: blog\n    collection: nope\n    rss: feed.xml\n", errCollectionUnknown},
+		{"unknown field", base + "name: blog\n    section: blog\n    rss: feed.xml\n    fields:\n      author: by\n", errFeedFieldUnknown},
+		{"duplicate name", base + "name: blog\n    section: blog\n    rss: a.xml\n  - name: blog\n    section: blog\n    rss: b.xml\n", errFeedNameDuplicate},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := loadTestConfig(t, tt.yaml)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_Fetcher(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := loadTestConfig(t, `
+fetcher:
+  timeout: "10s"
+  retries: 3
+  backoff: "500ms"
+  retry_statuses: [502, 503]
+`)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	want := FetcherConfig{Timeout: 10 * time.Second, Retries: 3, Backoff: 500 * time.Millisecond, RetryStatuses: []int{502, 503}}
+	if cfg.Fetcher.Timeout != want.Timeout || cfg.Fetcher.Retries != want.Retries || cfg.Fetcher.Backoff != want.Backoff ||
+		len(cfg.Fetcher.RetryStatuses) != 2 {
+		t.Errorf("fetcher = %+v, want %+v", cfg.Fetcher, want)
+	}
+
+	_, err = loadTestConfig(t, "fetcher:\n  retry_statuses: [200]\n")
+	if !errors.Is(err, errRetryStatusInvalid) {
+		t.Errorf("err = %v, want errRetryStatusInvalid", err)
+	}
+}
@@ -0,0 +1,214 @@
+package ssssg
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+var errFrontMatterUnclosed = errors.New("front matter is not closed")
+
+//nolint:gochecknoglobals
+var markdown = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+// frontMatter holds the front matter keys that configure the page itself.
+// Every other key becomes page data.
+type frontMatter struct {
+	Template string             `yaml:"template"`
+	Layout   string             `yaml:"layout"`
+	Output   string             `yaml:"output"`
+	Sitemap  *PageSitemapConfig `yaml:"sitemap"`
+}
+
+//nolint:gochecknoglobals
+var frontMatterKeys = []string{"template", "layout", "output", "sitemap"}
+
+// LoadContentPages turns every Markdown file (*.md) under dir into a page.
+// Front matter becomes page data, the rendered body is available as
+// .Content, and the output path follows the file path: "about.md" becomes
+// "about/index.html" and "blog/index.md" becomes "blog/index.html". Front
+// matter can override template, layout, output and sitemap settings; the
+// template defaults to cfg.Template. A missing dir yields no pages.
+func LoadContentPages(dir string, cfg ContentConfig) ([]PageConfig, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("stat content dir: %w", err)
+	}
+
+	if !info.IsDir() {
+		return nil, fmt.Errorf("content %s: %w", dir, errNotDirectory)
+	}
+
+	var files []string
+
+	err = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Skip dotfiles and dot directories
+		if strings.HasPrefix(d.Name(), ".") && p != dir {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if !d.IsDir() && strings.EqualFold(filepath.Ext(p), ".md") {
+			files = append(files, p)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walk content dir: %w", err)
+	}
+
+	sort.Strings(files)
+
+	pages := make([]PageConfig, 0, len(files))
+
+	for _, file := range files {
+		relPath, err := filepath.Rel(dir, file)
+		if err != nil {
+			return nil, fmt.Errorf("relative path: %w", err)
+		}
+
+		page, err := loadContentPage(file, filepath.ToSlash(relPath), cfg)
+		if err != nil {
+			return nil, fmt.Errorf("content %s: %w", filepath.ToSlash(relPath), err)
+		}
+
+		pages = append(pages, page)
+	}
+
+	return pages, nil
+}
+
+func loadContentPage(file, relPath string, cfg ContentConfig) (PageConfig, error) {
+	src, err := os.ReadFile(file)
+	if err != nil {
+		return PageConfig{}, fmt.Errorf("read: %w", err)
+	}
+
+	rawFront, body, err := splitFrontMatter(src)
+	if err != nil {
+		return PageConfig{}, err
+	}
+
+	var fm frontMatter
+
+	data := make(map[string]any)
+
+	if len(rawFront) > 0 {
+		if err := yaml.Unmarshal(rawFront, &fm); err != nil {
+			return PageConfig{}, fmt.Errorf("parse front matter: %w", err)
+		}
+
+		if err := yaml.Unmarshal(rawFront, &data); err != nil {
+			return PageConfig{}, fmt.Errorf("parse front matter: %w", err)
+		}
+
+		for _, key := range frontMatterKeys {
+			delete(data, key)
+		}
+	}
+
+	page := PageConfig{
+		Template: fm.Template,
+		Output:   fm.Output,
+		Layout:   fm.Layout,
+		Data:     data,
+		Sitemap:  fm.Sitemap,
+	}
+
+	if page.Template == "" {
+		page.Template = cfg.Template
+	}
+
+	if page.Layout == "" {
+		page.Layout = cfg.Layout
+	}
+
+	if page.Output == "" {
+		page.Output = contentOutput(relPath)
+	}
+
+	if page.Template == "" {
+		return PageConfig{}, errTemplateRequired
+	}
+
+	if err := validateOutput(page.Output); err != nil {
+		return PageConfig{}, err
+	}
+
+	if err := validatePageSitemap(page.Sitemap); err != nil {
+		return PageConfig{}, fmt.Errorf("sitemap: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := markdown.Convert(body, &buf); err != nil {
+		return PageConfig{}, fmt.Errorf("render markdown: %w", err)
+	}
+
+	page.content = template.HTML(buf.String()) //nolint:gosec
+
+	return page, nil
+}
+
+// splitFrontMatter separates a leading YAML front matter block delimited by
+// "---" lines from the Markdown body.
+func splitFrontMatter(src []byte) ([]byte, []byte, error) {
+	src = bytes.TrimPrefix(src, []byte("\xef\xbb\xbf"))
+
+	first, front, found := bytes.Cut(src, []byte("\n"))
+	if !found || strings.TrimSpace(string(first)) != "---" {
+		return nil, src, nil
+	}
+
+	for offset := 0; offset < len(front); {
+		line := front[offset:]
+		next := len(front)
+
+		if i := bytes.IndexByte(line, '\n'); i >= 0 {
+			line = line[:i]
+			next = offset + i + 1
+		}
+
+		if strings.TrimSpace(string(line)) == "---" {
+			return front[:offset], front[next:], nil
+		}
+
+		offset = next
+	}
+
+	return nil, nil, errFrontMatterUnclosed
+}
+
+// contentOutput maps a content file path to its output path using pretty
+// URLs: "index.md" -> "index.html", "about.md" -> "about/index.html".
+func contentOutput(relPath string) string {
+	base := strings.TrimSuffix(relPath, path.Ext(relPath))
+
+	if path.Base(base) == "index" {
+		return base + ".html"
+	}
+
+	return path.Join(base, "index.html")
+}
@@ -0,0 +1,224 @@
+package ssssg
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeContentFile(t *testing.T, dir, relPath, content string) {
+	t.Helper()
+
+	path := filepath.Join(dir, filepath.FromSlash(relPath))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadContentPages(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	writeContentFile(t, dir, "index.md", "# Welcome\n")
+	writeContentFile(t, dir, "about.md", "---\ntitle: About\ntags: [a, b]\n---\nHello *world*\n")
+	writeContentFile(t, dir, "blog/first-post.md", "---\ntitle: First\nlayout: _post.html\ntemplate: post.html\n---\nBody\n")
+	writeContentFile(t, dir, "blog/custom.md", "---\noutput: blog/custom.html\n---\nCustom\n")
+	writeContentFile(t, dir, "notes.txt", "ignored")
+	writeContentFile(t, dir, ".drafts/secret.md", "ignored")
+
+	pages, err := LoadContentPages(dir, ContentConfig{Template: "page.html", Layout: "_layout.html"})
+	if err != nil {
+		t.Fatalf("LoadContentPages failed: %v", err)
+	}
+
+	byOutput := make(map[string]PageConfig)
+	for _, p := range pages {
+		byOutput[p.Output] = p
+	}
+
+	if len(byOutput) != 4 {
+		t.Fatalf("outputs = %v, want 4 pages", byOutput)
+	}
+
+	index := byOutput["index.html"]
+	if !strings.Contains(string(index.content), "<h1>Welcome</h1>") {
+		t.Errorf("index content = %q", index.content)
+	}
+
+	about := byOutput["about/index.html"]
+	if about.Template != "page.html" || about.Layout != "_layout.html" {
+		t.Errorf("about = %+v", about)
+	}
+
+	if about.Data["title"] != "About" {
+		t.Errorf("about data = %v", about.Data)
+	}
+
+	if !strings.Contains(string(about.content), "<em>world</em>") {
+		t.Errorf("about content = %q", about.content)
+	}
+
+	post := byOutput["blog/first-post/index.html"]
+	if post.Template != "post.html" || post.Layout != "_post.html" {
+		t.Errorf("post = %+v", post)
+	}
+
+	if _, ok := post.Data["template"]; ok {
+		t.Errorf("reserved keys should not be page data: %v", post.Data)
+	}
+
+	if _, ok := byOutput["blog/custom.html"]; !ok {
+		t.Errorf("front matter output not honored: %v", byOutput)
+	}
+}
+
+func TestLoadContentPages_MissingDir(t *testing.T) {
+	t.Parallel()
+
+	pages, err := LoadContentPages(filepath.Join(t.TempDir(), "content"), ContentConfig{})
+	if err != nil || len(pages) != 0 {
+		t.Errorf("pages = %v, err = %v", pages, err)
+	}
+}
+
+func TestLoadContentPages_Errors(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeContentFile(t, dir, "page.md", "no template configured")
+
+	if _, err := LoadContentPages(dir, ContentConfig{}); !errors.Is(err, errTemplateRequired) {
+		t.Errorf("err = %v, want errTemplateRequired", err)
+	}
+
+	dir = t.TempDir()
+	writeContentFile(t, dir, "page.md", "---\ntitle: x\n")
+
+	if _, err := LoadContentPages(dir, ContentConfig{Template: "page.html"}); !errors.Is(err, errFrontMatterUnclosed) {
+		t.Errorf("err = %v, want errFrontMatterUnclosed", err)
+	}
+
+	dir = t.TempDir()
+	writeContentFile(t, dir, "page.md", "---\noutput: ../escape.html\n---\n")
+
+	if _, err := LoadContentPages(dir, ContentConfig{Template: "page.html"}); !errors.Is(err, errOutputPathTraversal) {
+		t.Errorf("err = %v, want errOutputPathTraversal", err)
+	}
+}
+
+func TestSplitFrontMatter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		src, front, body string
+	}{
+		{"---\na: 1\n---\nbody\n", "a: 1\n", "body\n"},
+		{"---\na: 1\n---", "a: 1\n", ""},
+		{"\xef\xbb\xbf---\r\na: 1\r\n---\r\nbody", "a: 1\r\n", "body"},
+		{"no front matter\n---\n", "", "no front matter\n---\n"},
+	}
+
+	for _, tt := range tests {
+		front, body, err := splitFrontMatter([]byte(tt.src))
+		if err != nil {
+			t.Errorf("splitFrontMatter(%q) failed: %v", tt.src, err)
+
+			continue
+		}
+
+		if string(front) != tt.front || string(body) != tt.body {
+			t.Errorf("splitFrontMatter(%q) = %q, %q; want %q, %q", tt.src, front, body, tt.front, tt.body)
+		}
+	}
+}
+
+func TestBuild_WithContent(t *testing.T) {
+	t.Parallel()
+
+	yaml := `
+global:
+  layout: "_layout.html"
+  data:
+    site_name: "Docs"
+
+content:
+  template: "page.html"
+`
+
+	dir := setupProject(t, yaml)
+
+	templates := map[string]string{
+		"_layout.html": `<html><title>{{ .Page.title }} - {{ .Global.site_name }}</title><body>{{ block "content" . }}{{ end }}</body></html>`,
+		"page.html":    `{{ define "content" }}<article>{{ .Content }}</article>{{ end }}`,
+	}
+	for name, content := range templates {
+		if err := os.WriteFile(filepath.Join(dir, "templates", name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeContentFile(t, filepath.Join(dir, "content"), "guide/install.md", "---\ntitle: Install\n---\n## Steps\n\n1. Download\n")
+
+	err := Build(t.Context(), BuildOptions{
+		ConfigPath: filepath.Join(dir, "site.yaml"),
+		Timeout:    10 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, "public", "guide", "install", "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	html := string(content)
+	if !strings.Contains(html, "<title>Install - Docs</title>") {
+		t.Errorf("missing title:\n%s", html)
+	}
+
+	if !strings.Contains(html, "<article><h2>Steps</h2>") || !strings.Contains(html, "<li>Download</li>") {
+		t.Errorf("missing rendered markdown:\n%s", html)
+	}
+}
+
+func TestBuild_ContentDirWithoutContentSection(t *testing.T) {
+	t.Parallel()
+
+	dir := setupProject(t, "pages:\n  - template: index.html\n    output: index.html\n")
+	if err := os.WriteFile(filepath.Join(dir, "templates", "index.html"), []byte("ok"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Notes without front matter would fail as pages without a template
+	writeContentFile(t, filepath.Join(dir, "content"), "README.md", "# Notes\n")
+
+	build := func(contentDir string) error {
+		return Build(t.Context(), BuildOptions{
+			ConfigPath: filepath.Join(dir, "site.yaml"),
+			ContentDir: contentDir,
+			Timeout:    10 * time.Second,
+		})
+	}
+
+	if err := build(""); err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "public", "README", "index.html")); !os.IsNotExist(err) {
+		t.Errorf("content page was built without a content section: %v", err)
+	}
+
+	// An explicit directory is always loaded
+	if err := build(filepath.Join(dir, "content")); !errors.Is(err, errTemplateRequired) {
+		t.Errorf("err = %v, want errTemplateRequired", err)
+	}
+}
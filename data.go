@@ -0,0 +1,136 @@
+package ssssg
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var errDataKeyConflict = errors.New("data key defined more than once")
+
+// LoadDataDir decodes every JSON, YAML, TOML and CSV file under dir into a
+// nested map mirroring the directory structure: "team/members.yaml" becomes
+// data["team"]["members"]. Other files, dotfiles and dot directories are
+// ignored. A key defined by two files, such as "team.yaml" next to a "team"
+// directory, is an error. A missing dir yields an empty map.
+func LoadDataDir(dir string) (map[string]any, error) {
+	data := make(map[string]any)
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return data, nil
+		}
+
+		return nil, fmt.Errorf("stat data dir: %w", err)
+	}
+
+	if !info.IsDir() {
+		return nil, fmt.Errorf("data %s: %w", dir, errNotDirectory)
+	}
+
+	var files []string
+
+	err = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Skip dotfiles and dot directories
+		if strings.HasPrefix(d.Name(), ".") && p != dir {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if !d.IsDir() && formatFromExt(strings.ToLower(filepath.Ext(p))) != FormatRaw {
+			files = append(files, p)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walk data dir: %w", err)
+	}
+
+	sort.Strings(files)
+
+	// maps created for directories, by slash path; "" is the root
+	dirs := map[string]map[string]any{"": data}
+
+	for _, file := range files {
+		relPath, err := filepath.Rel(dir, file)
+		if err != nil {
+			return nil, fmt.Errorf("relative path: %w", err)
+		}
+
+		relPath = filepath.ToSlash(relPath)
+
+		v, err := loadDataFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("data %s: %w", relPath, err)
+		}
+
+		key := strings.TrimSuffix(relPath, path.Ext(relPath))
+
+		parent, err := dataDirNode(dirs, path.Dir(key))
+		if err != nil {
+			return nil, fmt.Errorf("data %s: %w", relPath, err)
+		}
+
+		name := path.Base(key)
+		if _, ok := parent[name]; ok {
+			return nil, fmt.Errorf("data %s: %w: %s", relPath, errDataKeyConflict, key)
+		}
+
+		parent[name] = v
+	}
+
+	return data, nil
+}
+
+// dataDirNode returns the map of the directory at dirPath, creating it and
+// its parents. It fails if a file already claimed one of the keys.
+func dataDirNode(dirs map[string]map[string]any, dirPath string) (map[string]any, error) {
+	if dirPath == "." {
+		dirPath = ""
+	}
+
+	if node, ok := dirs[dirPath]; ok {
+		return node, nil
+	}
+
+	parentPath := path.Dir(dirPath)
+	parent, err := dataDirNode(dirs, parentPath)
+	if err != nil {
+		return nil, err
+	}
+
+	name := path.Base(dirPath)
+	if _, ok := parent[name]; ok {
+		return nil, fmt.Errorf("%w: %s", errDataKeyConflict, dirPath)
+	}
+
+	node := make(map[string]any)
+	parent[name] = node
+	dirs[dirPath] = node
+
+	return node, nil
+}
+
+// loadDataFile decodes a data file according to its extension.
+func loadDataFile(file string) (any, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read file: %w", err)
+	}
+
+	return decodeData(formatFromExt(strings.ToLower(filepath.Ext(file))), content)
+}
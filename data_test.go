@@ -0,0 +1,130 @@
+package ssssg
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func writeDataFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLoadDataDir(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeDataFiles(t, dir, map[string]string{
+		"site.yaml":              "name: ssssg\n",
+		"team/members.json":      `[{"name": "alice"}]`,
+		"team/leads/backend.yml": "- bob\n",
+		"releases.csv":           "version,date\n1.0,2024-01-01\n",
+		"settings.toml":          "debug = true\n",
+		"README.md":              "ignored",
+		"news.rss":               "<rss version=\"2.0\"><channel></channel></rss>",
+		".hidden.yaml":           "ignored: true\n",
+		".git/config.yaml":       "ignored: true\n",
+	})
+
+	got, err := LoadDataDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]any{
+		"site": map[string]any{"name": "ssssg"},
+		"team": map[string]any{
+			"members": []any{map[string]any{"name": "alice"}},
+			"leads":   map[string]any{"backend": []any{"bob"}},
+		},
+		"releases": []any{map[string]any{"version": "1.0", "date": "2024-01-01"}},
+		"settings": map[string]any{"debug": true},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+}
+
+func TestLoadDataDir_Missing(t *testing.T) {
+	t.Parallel()
+
+	got, err := LoadDataDir(filepath.Join(t.TempDir(), "data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 0 {
+		t.Errorf("expected empty data, got %v", got)
+	}
+}
+
+func TestLoadDataDir_Conflict(t *testing.T) {
+	t.Parallel()
+
+	tests := []map[string]string{
+		{"team.yaml": "a: 1\n", "team/members.yaml": "- alice\n"},
+		{"site.yaml": "a: 1\n", "site.json": `{"a": 1}`},
+	}
+
+	for _, files := range tests {
+		dir := t.TempDir()
+		writeDataFiles(t, dir, files)
+
+		if _, err := LoadDataDir(dir); !errors.Is(err, errDataKeyConflict) {
+			t.Errorf("files %v: expected errDataKeyConflict, got %v", files, err)
+		}
+	}
+}
+
+func TestBuild_WithDataDir(t *testing.T) {
+	t.Parallel()
+
+	yaml := `
+pages:
+  - template: "index.html"
+    output: "index.html"
+`
+
+	dir := setupProject(t, yaml)
+
+	writeDataFiles(t, filepath.Join(dir, "data"), map[string]string{
+		"team/members.yaml": "- name: alice\n- name: bob\n",
+	})
+
+	tmpl := `{{ range .Data.team.members }}<li>{{ .name }}</li>{{ end }}`
+	if err := os.WriteFile(filepath.Join(dir, "templates", "index.html"), []byte(tmpl), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := Build(t.Context(), BuildOptions{
+		ConfigPath: filepath.Join(dir, "site.yaml"),
+		Timeout:    10 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, "public", "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "<li>alice</li><li>bob</li>"; string(content) != want {
+		t.Errorf("got %q, want %q", content, want)
+	}
+}
@@ -0,0 +1,266 @@
+package ssssg
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+var (
+	errDownloadLocal    = errors.New("download requires a remote url")
+	errDownloadOptions  = errors.New("download must not set format, select, extract, encoding, as, type or follow")
+	errDownloadNoOutput = errors.New("fetcher has no output directory for downloads")
+)
+
+// download stores a remote file at entry.Download under the output
+// directory and returns its static file metadata. The response is streamed
+// to disk. With a disk cache, a copy is kept in the cache so that the file
+// can be restored within the TTL, in offline mode or after the output
+// directory was cleaned, and a stale copy is revalidated with a conditional
+// request.
+func (f *Fetcher) download(ctx context.Context, entry FetchEntry) (fetchResult, error) {
+	if f.outputDir == "" {
+		return fetchResult{}, errDownloadNoOutput
+	}
+
+	dest := filepath.Join(f.outputDir, entry.Download)
+	key := entry.cacheKey()
+
+	stored := dest
+	if f.disk != nil {
+		stored = f.disk.dataPath(key)
+	}
+
+	cached, ok := f.disk.load(key)
+	if ok && !fileExists(stored) {
+		cached, ok = nil, false
+	}
+
+	switch {
+	case f.offline:
+		if !ok {
+			return fetchResult{}, errOfflineCacheMiss
+		}
+	case ok && cached.fresh(entry.TTL):
+	default:
+		var header http.Header
+
+		err := f.withRetry(ctx, entry, func() error {
+			var err error
+
+			header, err = f.downloadOnce(ctx, entry, stored, cached)
+
+			return err
+		})
+		if err != nil {
+			return fetchResult{}, err
+		}
+
+		err = f.disk.store(key, &fetchCacheEntry{
+			URL:       redactURL(entry.URL),
+			Header:    header,
+			FetchedAt: time.Now(),
+		})
+		if err != nil {
+			return fetchResult{}, err
+		}
+	}
+
+	if stored != dest {
+		if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+			return fetchResult{}, fmt.Errorf("create dir for %s: %w", entry.Download, err)
+		}
+
+		if err := copyFile(stored, dest); err != nil {
+			return fetchResult{}, err
+		}
+	}
+
+	info := scanFile(dest, entry.Download)
+
+	return fetchResult{file: &info}, nil
+}
+
+// downloadOnce streams a single response into path through a temporary
+// file, so that a failed download never leaves a partial file behind. A 304
+// Not Modified keeps the existing file.
+func (f *Fetcher) downloadOnce(ctx context.Context, entry FetchEntry, path string, cached *fetchCacheEntry) (http.Header, error) {
+	ctx, cancel := f.requestContext(ctx)
+	defer cancel()
+
+	resp, err := f.send(ctx, entry, cached)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return revalidatedHeader(cached.Header, resp.Header), nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create dir for %s: %w", path, err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".download-*")
+	if err != nil {
+		return nil, fmt.Errorf("create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	err = copyLimited(tmp, resp.Body, f.maxBodySize(entry))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("download %s: %w", entry.describe(), err)
+	}
+
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil { //nolint:gosec
+		return nil, fmt.Errorf("chmod %s: %w", tmp.Name(), err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return nil, fmt.Errorf("rename %s: %w", path, err)
+	}
+
+	return resp.Header, nil
+}
+
+// downloadPaths returns the distinct download paths of entry and its
+// fallbacks. Only one source of the chain is saved, so they never collide
+// with each other.
+func downloadPaths(entry FetchEntry) []string {
+	var paths []string
+
+	if entry.Download != "" {
+		paths = append(paths, entry.Download)
+	}
+
+	for _, fallback := range entry.Fallback {
+		for _, p := range downloadPaths(fallback) {
+			if !slices.Contains(paths, p) {
+				paths = append(paths, p)
+			}
+		}
+	}
+
+	return paths
+}
+
+func validateDownload(entry FetchEntry) error {
+	if entry.Download == "" {
+		return nil
+	}
+
+	if !isRemote(entry.URL) {
+		return errDownloadLocal
+	}
+
+	if entry.Download == "." || filepath.Clean(entry.Download) == "." {
+		return errOutputRequired
+	}
+
+	if err := validateOutput(entry.Download); err != nil {
+		return err
+	}
+
+	if (entry.Format != "" && entry.Format != FormatRaw) || entry.Select != "" || entry.Extract != "" ||
+		entry.Encoding != "" || entry.As != "" || entry.Type != "" || entry.Follow != nil {
+		return errDownloadOptions
+	}
+
+	return nil
+}
+
+// downloadOutputs returns the download paths of the fetch entries in cfg.
+// An entry used in several places is fetched once and counted once.
+func downloadOutputs(cfg *Config) []plannedOutput {
+	var outputs []plannedOutput
+
+	seen := make(map[string]struct{})
+	add := func(entry FetchEntry) {
+		key := entry.cacheKey()
+		if _, ok := seen[key]; ok {
+			return
+		}
+
+		seen[key] = struct{}{}
+
+		for _, p := range downloadPaths(entry) {
+			outputs = append(outputs, plannedOutput{path: p, owner: "download " + entry.describe()})
+		}
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(cfg.Global.Fetch)) {
+		add(cfg.Global.Fetch[key])
+	}
+
+	for _, page := range cfg.Pages {
+		for _, key := range slices.Sorted(maps.Keys(page.Fetch)) {
+			add(page.Fetch[key])
+		}
+	}
+
+	for _, c := range cfg.Collections {
+		if c.Source != nil {
+			add(*c.Source)
+		}
+	}
+
+	return outputs
+}
+
+// checkDownloadOutputs rejects downloads that would be written over a file
+// of the static directory or an output known from the config alone: pages
+// with all their paginated outputs, sitemap files and feeds. It runs before
+// anything is fetched, since downloads are written to the output directory
+// as they are fetched. Collection and content outputs are checked once they
+// are known, before downloads are fetched.
+func checkDownloadOutputs(cfg *Config, downloads []plannedOutput, staticDir string) error {
+	sitemapOutput := ""
+	if cfg.Sitemap != nil {
+		sitemapOutput = cmp.Or(cfg.Sitemap.Output, "sitemap.xml")
+	}
+
+	for _, d := range downloads {
+		p := filepath.ToSlash(filepath.Clean(d.path))
+
+		collides := func(owner string) error {
+			return fmt.Errorf("%w: %s (%s and %s)", errDuplicateOutput, d.path, owner, d.owner)
+		}
+
+		if _, err := os.Stat(filepath.Join(staticDir, filepath.FromSlash(p))); err == nil {
+			return collides("static file")
+		}
+
+		for _, page := range cfg.Pages {
+			output := filepath.ToSlash(filepath.Clean(page.Output))
+			if p == output || (page.Paginate != nil && isPaginatedOutput(output, p)) {
+				return collides("page " + page.Output)
+			}
+		}
+
+		if sitemapOutput != "" && isSitemapFile(filepath.ToSlash(filepath.Clean(sitemapOutput)), p) {
+			return collides("sitemap")
+		}
+
+		for _, f := range cfg.Feeds {
+			for _, output := range []string{f.RSS, f.Atom} {
+				if output != "" && p == filepath.ToSlash(filepath.Clean(output)) {
+					return collides("feed " + f.Name)
+				}
+			}
+		}
+	}
+
+	return nil
+}
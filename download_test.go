@@ -0,0 +1,256 @@
+package ssssg
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestFetcher_Download(t *testing.T) {
+	t.Parallel()
+
+	img := testPNG(t, 3, 2)
+
+	var hits atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+
+			return
+		}
+
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write(img)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	outputDir := filepath.Join(dir, "public")
+	cacheDir := filepath.Join(dir, "cache")
+	entry := FetchEntry{URL: srv.URL + "/hero.png", Download: "img/hero.png", TTL: time.Hour}
+	want := StaticFileInfo{Path: "img/hero.png", Size: int64(len(img)), Width: 3, Height: 2}
+
+	resolve := func(t *testing.T, f *Fetcher) {
+		t.Helper()
+
+		got, err := f.Resolve(context.Background(), entry)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got != want {
+			t.Errorf("got %#v, want %#v", got, want)
+		}
+
+		content, err := os.ReadFile(filepath.Join(outputDir, "img", "hero.png"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(content, img) {
+			t.Error("downloaded file differs from the response")
+		}
+	}
+
+	resolve(t, NewFetcher(dir, srv.Client(), WithOutputDir(outputDir), WithCacheDir(cacheDir)))
+
+	// A cleaned output directory is restored from the cache within the TTL
+	if err := os.RemoveAll(outputDir); err != nil {
+		t.Fatal(err)
+	}
+
+	resolve(t, NewFetcher(dir, srv.Client(), WithOutputDir(outputDir), WithCacheDir(cacheDir)))
+	resolve(t, NewFetcher(dir, nil, WithOutputDir(outputDir), WithCacheDir(cacheDir), WithOffline(true)))
+
+	if n := hits.Load(); n != 1 {
+		t.Errorf("server was hit %d times, want 1", n)
+	}
+
+	// A stale copy is revalidated and kept on 304 Not Modified
+	entry.TTL = 0
+
+	resolve(t, NewFetcher(dir, srv.Client(), WithOutputDir(outputDir), WithCacheDir(cacheDir)))
+
+	if n := hits.Load(); n != 2 {
+		t.Errorf("server was hit %d times, want 2", n)
+	}
+}
+
+func TestFetcher_DownloadFailureKeepsNoPartialFile(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	outputDir := t.TempDir()
+	f := NewFetcher(t.TempDir(), srv.Client(), WithOutputDir(outputDir))
+
+	_, err := f.Resolve(context.Background(), FetchEntry{URL: srv.URL + "/missing.pdf", Download: "docs/missing.pdf"})
+	if !errors.Is(err, errHTTPStatus) {
+		t.Fatalf("expected errHTTPStatus, got %v", err)
+	}
+
+	entries, _ := os.ReadDir(filepath.Join(outputDir, "docs"))
+	if len(entries) != 0 {
+		t.Errorf("expected no files, got %v", entries)
+	}
+}
+
+func TestValidateDownload(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		entry FetchEntry
+		want  error
+	}{
+		{FetchEntry{URL: "https://cdn.example.com/a.png", Download: "img/a.png"}, nil},
+		{FetchEntry{URL: "static/a.png", Download: "img/a.png"}, errDownloadLocal},
+		{FetchEntry{URL: "https://cdn.example.com/a.png", Download: "../a.png"}, errOutputPathTraversal},
+		{FetchEntry{URL: "https://cdn.example.com/a.png", Download: "img/a.png", Format: FormatJSON}, errDownloadOptions},
+	}
+
+	for _, tt := range tests {
+		if err := validateDownload(tt.entry); !errors.Is(err, tt.want) {
+			t.Errorf("validateDownload(%+v) = %v, want %v", tt.entry, err, tt.want)
+		}
+	}
+}
+
+func TestBuild_DownloadInStatic(t *testing.T) {
+	t.Parallel()
+
+	img := testPNG(t, 4, 5)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(img)
+	}))
+	defer srv.Close()
+
+	dir := setupProject(t, `
+global:
+  fetch:
+    logo:
+      url: "`+srv.URL+`/logo.png"
+      download: "img/logo.png"
+pages:
+  - template: "index.html"
+    output: "index.html"
+`)
+
+	tmpl := `{{ $img := index .Static "img/logo.png" }}<img src="/{{ .Global.logo.Path }}" width="{{ $img.Width }}" height="{{ $img.Height }}">`
+	if err := os.WriteFile(filepath.Join(dir, "templates", "index.html"), []byte(tmpl), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := Build(t.Context(), BuildOptions{
+		ConfigPath: filepath.Join(dir, "site.yaml"),
+		Timeout:    10 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, "public", "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := `<img src="/img/logo.png" width="4" height="5">`; string(content) != want {
+		t.Errorf("got %q, want %q", content, want)
+	}
+}
+
+func TestBuild_DownloadCollision(t *testing.T) {
+	t.Parallel()
+
+	var downloads atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/items.json" {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`[{"slug": "logo"}]`))
+
+			return
+		}
+
+		downloads.Add(1)
+		_, _ = w.Write([]byte("downloaded"))
+	}))
+	defer srv.Close()
+
+	download := func(path string) string {
+		return "global:\n  base_url: \"https://example.com\"\n  fetch:\n    file:\n      url: \"" + srv.URL + "/file\"\n      download: \"" + path + "\"\n"
+	}
+
+	tests := []struct {
+		name   string
+		yaml   string
+		static string // file created in static/
+	}{
+		{name: "page", yaml: download("index.html") + "pages:\n  - template: t.html\n    output: index.html\n"},
+		{name: "paginated", yaml: download("blog/page/3/index.html") + "pages:\n  - template: t.html\n    output: blog/index.html\n    data:\n      posts: []\n    paginate:\n      items: posts\n      per_page: 1\n"},
+		{name: "sitemap", yaml: download("sitemap-2.xml") + "sitemap: {}\n"},
+		{name: "feed", yaml: download("feed.xml") + "feeds:\n  - name: all\n    pages: \"*\"\n    rss: feed.xml\n"},
+		{name: "collection", yaml: download("items/logo.html") + "collections:\n  - template: t.html\n    output: \"items/{{ .slug }}.html\"\n    source: \"" + srv.URL + "/items.json\"\n"},
+		{name: "static", yaml: download("img/logo.png"), static: "img/logo.png"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := setupProject(t, tt.yaml)
+			if err := os.WriteFile(filepath.Join(dir, "templates", "t.html"), []byte("x"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.static != "" {
+				path := filepath.Join(dir, "static", tt.static)
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					t.Fatal(err)
+				}
+
+				if err := os.WriteFile(path, []byte("static"), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := Build(t.Context(), BuildOptions{
+				ConfigPath: filepath.Join(dir, "site.yaml"),
+				Timeout:    10 * time.Second,
+			})
+			if !errors.Is(err, errDuplicateOutput) {
+				t.Errorf("err = %v, want errDuplicateOutput", err)
+			}
+		})
+	}
+
+	// Nothing is downloaded over another output before the error
+	if n := downloads.Load(); n != 0 {
+		t.Errorf("downloads = %d, want 0", n)
+	}
+}
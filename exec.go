@@ -0,0 +1,165 @@
+package ssssg
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+const (
+	// execWaitDelay is how long a killed command may keep its output open.
+	execWaitDelay = 500 * time.Millisecond
+
+	// execStderrTail is how much of the end of stderr is kept for errors.
+	execStderrTail = 4 << 10
+)
+
+var (
+	errExecEmpty   = errors.New("exec command is empty")
+	errExecWithURL = errors.New("fetch must not have both url and exec")
+	errExecOptions = errors.New("exec must not set request, type or follow options")
+)
+
+// ExecCommand is a command whose stdout is used as fetched content. In
+// site.yaml a string runs through "sh -c" like pipeline commands, and a list
+// runs the program directly with the given arguments:
+//
+//	exec: "git log --oneline -5"
+//	exec: ["python3", "scripts/export.py", "--json"]
+type ExecCommand struct {
+	Shell string   // command line for sh -c
+	Args  []string // program and arguments, run without a shell
+}
+
+// UnmarshalYAML accepts a command line or a list of arguments.
+func (c *ExecCommand) UnmarshalYAML(unmarshal func(any) error) error {
+	var shell string
+	if err := unmarshal(&shell); err == nil {
+		*c = ExecCommand{Shell: shell}
+
+		return nil
+	}
+
+	var args []string
+	if err := unmarshal(&args); err != nil {
+		return err
+	}
+
+	*c = ExecCommand{Args: args}
+
+	return nil
+}
+
+// String returns the command for log lines and errors.
+func (c ExecCommand) String() string {
+	if c.Shell != "" {
+		return c.Shell
+	}
+
+	return strings.Join(c.Args, " ")
+}
+
+func (c ExecCommand) empty() bool {
+	return strings.TrimSpace(c.Shell) == "" && (len(c.Args) == 0 || c.Args[0] == "")
+}
+
+// shellCommand runs command through sh -c, as static pipelines do.
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
+
+// fetchExec runs the command in the base directory and returns its stdout.
+// The command is killed when ctx is done or its output grows past limit
+// bytes. On failure the error includes the end of stderr.
+func (f *Fetcher) fetchExec(ctx context.Context, c ExecCommand, limit int64) (string, error) {
+	var cmd *exec.Cmd
+	if c.Shell != "" {
+		cmd = shellCommand(ctx, c.Shell)
+	} else {
+		cmd = exec.CommandContext(ctx, c.Args[0], c.Args[1:]...) //nolint:gosec // commands come from site.yaml
+	}
+
+	cmd.Dir = f.baseDir
+	// Killing sh leaves its children holding stdout and stderr open; stop
+	// waiting for them shortly after, so ctx bounds the whole command.
+	cmd.WaitDelay = execWaitDelay
+
+	var stdout bytes.Buffer
+
+	stderr := &tailBuffer{limit: execStderrTail}
+	cmd.Stderr = stderr
+
+	pipe, err := cmd.StdoutPipe()
+	if err != nil {
+		return "", fmt.Errorf("command %q: %w", c.String(), err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return "", fmt.Errorf("command %q: %w", c.String(), err)
+	}
+
+	if err := copyLimited(&stdout, pipe, limit); errors.Is(err, errBodyTooLarge) {
+		// Closing the pipe also stops children of sh that still write to it
+		_ = cmd.Process.Kill()
+		_ = pipe.Close()
+		_ = cmd.Wait()
+
+		return "", fmt.Errorf("command %q: %w", c.String(), err)
+	}
+
+	if err := cmd.Wait(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("command %q: %w: %s", c.String(), err, msg)
+		}
+
+		return "", fmt.Errorf("command %q: %w", c.String(), err)
+	}
+
+	return stdout.String(), nil
+}
+
+// tailBuffer keeps the last limit bytes written to it, so that a noisy
+// command cannot grow its stderr without bound.
+type tailBuffer struct {
+	limit int
+	buf   []byte
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	if len(p) > b.limit {
+		p = p[len(p)-b.limit:]
+	}
+
+	if drop := len(b.buf) + len(p) - b.limit; drop > 0 {
+		b.buf = b.buf[drop:]
+	}
+
+	b.buf = append(b.buf, p...)
+
+	return n, nil
+}
+
+func (b *tailBuffer) String() string {
+	return string(b.buf)
+}
+
+func validateExec(entry FetchEntry) error {
+	if entry.URL != "" {
+		return errExecWithURL
+	}
+
+	if entry.Exec.empty() {
+		return errExecEmpty
+	}
+
+	if entry.hasRequestOptions() {
+		return errExecOptions
+	}
+
+	return nil
+}
@@ -0,0 +1,180 @@
+package ssssg
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-yaml"
+)
+
+func TestFetcher_ResolveExec(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "data.json"), []byte(`{"name": "ssssg"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	f := NewFetcher(dir, nil)
+
+	tests := []struct {
+		name  string
+		entry FetchEntry
+		want  any
+	}{
+		{
+			name:  "shell raw",
+			entry: FetchEntry{Exec: &ExecCommand{Shell: "echo hello | tr a-z A-Z"}},
+			want:  "HELLO\n",
+		},
+		{
+			name:  "shell runs in base dir",
+			entry: FetchEntry{Exec: &ExecCommand{Shell: "cat data.json"}, Format: FormatJSON},
+			want:  map[string]any{"name": "ssssg"},
+		},
+		{
+			name:  "args without shell",
+			entry: FetchEntry{Exec: &ExecCommand{Args: []string{"echo", "$HOME", "a b"}}},
+			want:  "$HOME a b\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := f.Resolve(context.Background(), tt.entry)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFetcher_ExecFailure(t *testing.T) {
+	t.Parallel()
+
+	f := NewFetcher(t.TempDir(), nil)
+
+	_, err := f.Resolve(context.Background(), FetchEntry{Exec: &ExecCommand{Shell: "echo partial; echo 'no such table' >&2; exit 3"}})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	for _, want := range []string{"exec: echo partial", "exit status 3", "no such table"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not contain %q", err, want)
+		}
+	}
+}
+
+func TestFetcher_ExecStderrTail(t *testing.T) {
+	t.Parallel()
+
+	f := NewFetcher(t.TempDir(), nil)
+
+	// About 1 MiB of noise on stderr before the actual error
+	command := "head -c 1048576 /dev/zero | tr '\\0' x >&2; echo 'fatal: last line' >&2; exit 1"
+
+	_, err := f.Resolve(context.Background(), FetchEntry{Exec: &ExecCommand{Shell: command}})
+	if err == nil || !strings.Contains(err.Error(), "fatal: last line") {
+		t.Fatalf("err = %v, want the end of stderr", err)
+	}
+
+	if n := len(err.Error()); n > 2*execStderrTail {
+		t.Errorf("error is %d bytes, want stderr cut to %d", n, execStderrTail)
+	}
+}
+
+func TestTailBuffer(t *testing.T) {
+	t.Parallel()
+
+	b := &tailBuffer{limit: 5}
+
+	for _, s := range []string{"abc", "defg", "hi", "0123456789"} {
+		if n, err := b.Write([]byte(s)); n != len(s) || err != nil {
+			t.Errorf("Write(%q) = %d, %v", s, n, err)
+		}
+	}
+
+	if got := b.String(); got != "56789" {
+		t.Errorf("String() = %q, want %q", got, "56789")
+	}
+
+	b = &tailBuffer{limit: 5}
+	_, _ = b.Write([]byte("abc"))
+	_, _ = b.Write([]byte("def"))
+
+	if got := b.String(); got != "bcdef" {
+		t.Errorf("String() = %q, want %q", got, "bcdef")
+	}
+}
+
+func TestFetcher_ExecTimeout(t *testing.T) {
+	t.Parallel()
+
+	f := NewFetcher(t.TempDir(), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+
+	_, err := f.Resolve(ctx, FetchEntry{Exec: &ExecCommand{Args: []string{"sleep", "10"}}})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("command was not stopped by the context, took %s", elapsed)
+	}
+}
+
+func TestFetcher_ExecTimeoutChildProcess(t *testing.T) {
+	t.Parallel()
+
+	f := NewFetcher(t.TempDir(), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+
+	// sleep runs as a child of sh and keeps stdout open after sh is killed
+	_, err := f.Resolve(ctx, FetchEntry{Exec: &ExecCommand{Shell: "echo hi; sleep 3; echo done"}})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("command was not stopped by the context, took %s", elapsed)
+	}
+}
+
+func TestExecCommand_UnmarshalYAML(t *testing.T) {
+	t.Parallel()
+
+	var fetch map[string]FetchEntry
+
+	src := "log: {exec: \"git log -1\"}\nexport: {exec: [python3, export.py], format: json}\n"
+	if err := yaml.Unmarshal([]byte(src), &fetch); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := fetch["log"].Exec; got == nil || got.Shell != "git log -1" || got.Args != nil {
+		t.Errorf("log exec = %#v", got)
+	}
+
+	if got := fetch["export"].Exec; got == nil || got.Shell != "" || strings.Join(got.Args, ",") != "python3,export.py" {
+		t.Errorf("export exec = %#v", got)
+	}
+}
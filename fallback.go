@@ -0,0 +1,80 @@
+package ssssg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Resolve fetches an entry and decodes it according to its format. The raw
+// format (the default) returns the content as a string. Glob and directory
+// sources return the decoded files as a map or a list.
+//
+// When the entry fails, its fallbacks are tried in order, and an optional
+// entry yields its default when they fail too. Both log a warning.
+func (f *Fetcher) Resolve(ctx context.Context, entry FetchEntry) (any, error) {
+	v, err := f.resolve(ctx, entry)
+	if err == nil || !entry.hasFallback() {
+		return v, err
+	}
+
+	errs := []error{err}
+
+	for _, fallback := range entry.Fallback {
+		if fallback.Format == "" {
+			fallback.Format = entry.Format
+		}
+
+		v, fallbackErr := f.Resolve(ctx, fallback)
+		if fallbackErr == nil {
+			f.warnf("Warning: %s; using fallback %s", joinErrors(errs), fallback.describe())
+
+			return v, nil
+		}
+
+		errs = append(errs, fallbackErr)
+	}
+
+	if ctx.Err() == nil && entry.optional() {
+		f.warnf("Warning: %s; using the default value", joinErrors(errs))
+
+		return entry.Default, nil
+	}
+
+	return nil, errors.Join(errs...)
+}
+
+// warnf logs a warning once per Fetcher, however many pages use the entry.
+func (f *Fetcher) warnf(format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+
+	f.mu.Lock()
+	_, seen := f.warned[msg]
+	f.warned[msg] = struct{}{}
+	f.mu.Unlock()
+
+	if !seen {
+		f.logf("%s", msg)
+	}
+}
+
+func joinErrors(errs []error) string {
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+// optional reports whether the entry yields its default instead of failing.
+// Setting a default makes an entry optional.
+func (e FetchEntry) optional() bool {
+	return e.Optional || e.Default != nil
+}
+
+// hasFallback reports whether a failure of the entry is recovered from.
+func (e FetchEntry) hasFallback() bool {
+	return len(e.Fallback) > 0 || e.optional()
+}
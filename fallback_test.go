@@ -0,0 +1,153 @@
+package ssssg
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// logRecorder collects log lines of a Fetcher.
+type logRecorder struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (r *logRecorder) logf(format string, args ...any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lines = append(r.lines, fmt.Sprintf(format, args...))
+}
+
+func (r *logRecorder) String() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return strings.Join(r.lines, "\n")
+}
+
+func TestFetcher_ResolveFallback(t *testing.T) {
+	t.Parallel()
+
+	var hits atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "snapshot.json"), []byte(`{"status": "snapshot"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var log logRecorder
+
+	f := NewFetcher(dir, srv.Client(), WithLogf(log.logf))
+
+	entry := FetchEntry{
+		URL:    srv.URL + "/status",
+		Format: FormatJSON,
+		Fallback: []FetchEntry{
+			{URL: srv.URL + "/mirror"},
+			{URL: "snapshot.json"},
+		},
+	}
+
+	if err := f.Prefetch(context.Background(), entry); err != nil {
+		t.Fatal(err)
+	}
+
+	for range 2 {
+		got, err := f.Resolve(context.Background(), entry)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if want := map[string]any{"status": "snapshot"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got %#v, want %#v", got, want)
+		}
+	}
+
+	if n := hits.Load(); n != 2 {
+		t.Errorf("failed sources were requested %d times, want 2", n)
+	}
+
+	if n := strings.Count(log.String(), "using fallback snapshot.json"); n != 1 {
+		t.Errorf("expected one fallback warning, got log:\n%s", log.String())
+	}
+}
+
+func TestFetcher_ResolveDefault(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	var log logRecorder
+
+	f := NewFetcher(t.TempDir(), srv.Client(), WithLogf(log.logf))
+
+	tests := []struct {
+		name  string
+		entry FetchEntry
+		want  any
+	}{
+		{
+			name:  "default",
+			entry: FetchEntry{URL: srv.URL + "/widget", Default: []any{}},
+			want:  []any{},
+		},
+		{
+			name:  "optional without default",
+			entry: FetchEntry{URL: srv.URL + "/other", Optional: true, Fallback: []FetchEntry{{URL: "missing.json"}}},
+			want:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := f.Resolve(context.Background(), tt.entry)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %#v, want %#v", tt.name, got, tt.want)
+		}
+	}
+
+	if !strings.Contains(log.String(), "using the default value") || !strings.Contains(log.String(), "missing.json") {
+		t.Errorf("expected default warnings naming every failed source, got log:\n%s", log.String())
+	}
+}
+
+func TestFetcher_ResolveFallbackFails(t *testing.T) {
+	t.Parallel()
+
+	f := NewFetcher(t.TempDir(), nil)
+
+	_, err := f.Resolve(context.Background(), FetchEntry{
+		URL:      "missing.json",
+		Fallback: []FetchEntry{{URL: "also-missing.json"}},
+	})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	for _, want := range []string{"missing.json", "also-missing.json"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %s", err, want)
+		}
+	}
+}
@@ -0,0 +1,413 @@
+package ssssg
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html/template"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	feedFieldTitle   = "title"
+	feedFieldDate    = "date"
+	feedFieldSummary = "summary"
+	feedFieldContent = "content"
+)
+
+// defaultFeedFields maps feed fields to the page data keys read by default.
+// An empty content key means the rendered Markdown body.
+//
+//nolint:gochecknoglobals
+var defaultFeedFields = map[string]string{
+	feedFieldTitle:   "title",
+	feedFieldDate:    "date",
+	feedFieldSummary: "summary",
+	feedFieldContent: "",
+}
+
+// feedDateLayouts are the accepted formats of string dates.
+//
+//nolint:gochecknoglobals
+var feedDateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+}
+
+// FeedInfo is available to templates as .Feeds.<name> for
+// <link rel="alternate"> tags. URLs are site-relative and empty when the
+// format is not generated.
+type FeedInfo struct {
+	Title   string
+	RSSURL  string
+	AtomURL string
+}
+
+func newFeedInfos(feeds []FeedConfig) map[string]*FeedInfo {
+	infos := make(map[string]*FeedInfo, len(feeds))
+
+	for _, f := range feeds {
+		info := &FeedInfo{Title: f.Title}
+
+		if f.RSS != "" {
+			info.RSSURL = outputURL(f.RSS)
+		}
+
+		if f.Atom != "" {
+			info.AtomURL = outputURL(f.Atom)
+		}
+
+		infos[f.Name] = info
+	}
+
+	return infos
+}
+
+// feedItem is a page mapped to the feed fields.
+type feedItem struct {
+	Title   string
+	URL     string // absolute
+	Date    time.Time
+	Summary string
+	Content string
+}
+
+// feedPages selects the pages of a feed. Every configured selector must match.
+func feedPages(f FeedConfig, pages []*PageInfo, collections map[string][]*PageInfo) []*PageInfo {
+	candidates := pages
+	if f.Collection != "" {
+		candidates = collections[f.Collection]
+	}
+
+	var selected []*PageInfo
+
+	for _, page := range candidates {
+		output := path.Clean(filepath.ToSlash(page.Output))
+
+		if f.Pages != "" {
+			if ok, _ := path.Match(f.Pages, output); !ok {
+				continue
+			}
+		}
+
+		if f.Section != "" && !inSection(output, f.Section) {
+			continue
+		}
+
+		if f.Tag != "" && !hasTag(page.Data["tags"], f.Tag) {
+			continue
+		}
+
+		selected = append(selected, page)
+	}
+
+	return selected
+}
+
+// inSection reports whether output lies below the section directory. The
+// section's own index page is the list page, not an entry.
+func inSection(output, section string) bool {
+	section = path.Clean(section)
+
+	dir, rest, found := strings.Cut(output, "/")
+	if !found || dir != section {
+		return false
+	}
+
+	return rest != "index.html"
+}
+
+func hasTag(tags any, tag string) bool {
+	switch v := tags.(type) {
+	case []any:
+		return slices.ContainsFunc(v, func(t any) bool { return fmt.Sprint(t) == tag })
+	case []string:
+		return slices.Contains(v, tag)
+	case string:
+		return v == tag
+	default:
+		return false
+	}
+}
+
+// feedItems maps the selected pages to feed items, newest first. Undated
+// items keep their order after the dated ones. A positive limit caps the
+// number of items.
+func feedItems(f FeedConfig, baseURL string, pages []*PageInfo) []feedItem {
+	fields := make(map[string]string, len(defaultFeedFields))
+	for field, key := range defaultFeedFields {
+		fields[field] = key
+	}
+
+	for field, key := range f.Fields {
+		fields[field] = key
+	}
+
+	items := make([]feedItem, 0, len(pages))
+
+	for _, page := range pages {
+		item := feedItem{
+			Title:   feedString(page.Data[fields[feedFieldTitle]]),
+			URL:     absoluteURL(baseURL, page.URL),
+			Date:    feedDate(page.Data[fields[feedFieldDate]]),
+			Summary: feedString(page.Data[fields[feedFieldSummary]]),
+			Content: string(page.Content),
+		}
+
+		if key := fields[feedFieldContent]; key != "" {
+			item.Content = feedString(page.Data[key])
+		}
+
+		items = append(items, item)
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].Date.IsZero() || items[j].Date.IsZero() {
+			return !items[i].Date.IsZero() && items[j].Date.IsZero()
+		}
+
+		return items[i].Date.After(items[j].Date)
+	})
+
+	if f.Limit > 0 && len(items) > f.Limit {
+		items = items[:f.Limit]
+	}
+
+	return items
+}
+
+func feedString(v any) string {
+	switch s := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return s
+	case template.HTML:
+		return string(s)
+	default:
+		return fmt.Sprint(s)
+	}
+}
+
+// feedDate parses a date value. Unparsable or missing dates yield the zero time.
+func feedDate(v any) time.Time {
+	switch d := v.(type) {
+	case time.Time:
+		return d
+	case string:
+		for _, layout := range feedDateLayouts {
+			if t, err := time.Parse(layout, d); err == nil {
+				return t
+			}
+		}
+	}
+
+	return time.Time{}
+}
+
+// feedUpdated returns the newest item date, or the zero time when no item
+// is dated. The build time is never used so that unchanged feeds are written
+// byte for byte the same.
+func feedUpdated(items []feedItem) time.Time {
+	for _, item := range items {
+		if !item.Date.IsZero() {
+			return item.Date
+		}
+	}
+
+	return time.Time{}
+}
+
+type rssFeed struct {
+	XMLName   xml.Name   `xml:"rss"`
+	Version   string     `xml:"version,attr"`
+	ContentNS string     `xml:"xmlns:content,attr"`
+	AtomNS    string     `xml:"xmlns:atom,attr"`
+	Channel   rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	AtomLink      atomLink  `xml:"atom:link"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate,omitempty"`
+	Description string  `xml:"description,omitempty"`
+	Content     string  `xml:"content:encoded,omitempty"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"feed"`
+	XMLNS   string      `xml:"xmlns,attr"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Author  atomPerson  `xml:"author"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomEntry struct {
+	Title   string    `xml:"title"`
+	ID      string    `xml:"id"`
+	Link    atomLink  `xml:"link"`
+	Updated string    `xml:"updated"`
+	Summary *atomText `xml:"summary,omitempty"`
+	Content *atomText `xml:"content,omitempty"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+func newRSSFeed(f FeedConfig, baseURL string, items []feedItem) rssFeed {
+	description := f.Description
+	if description == "" {
+		description = f.Title
+	}
+
+	feed := rssFeed{
+		Version:   "2.0",
+		ContentNS: "http://purl.org/rss/1.0/modules/content/",
+		AtomNS:    "http://www.w3.org/2005/Atom",
+		Channel: rssChannel{
+			Title:       f.Title,
+			Link:        absoluteURL(baseURL, "/"),
+			Description: description,
+			AtomLink:    atomLink{Href: absoluteURL(baseURL, outputURL(f.RSS)), Rel: "self", Type: "application/rss+xml"},
+		},
+	}
+
+	if updated := feedUpdated(items); !updated.IsZero() {
+		feed.Channel.LastBuildDate = updated.Format(time.RFC1123Z)
+	}
+
+	for _, item := range items {
+		ri := rssItem{
+			Title:       item.Title,
+			Link:        item.URL,
+			GUID:        rssGUID{IsPermaLink: true, Value: item.URL},
+			Description: item.Summary,
+			Content:     item.Content,
+		}
+
+		if !item.Date.IsZero() {
+			ri.PubDate = item.Date.Format(time.RFC1123Z)
+		}
+
+		feed.Channel.Items = append(feed.Channel.Items, ri)
+	}
+
+	return feed
+}
+
+func newAtomFeed(f FeedConfig, baseURL string, items []feedItem) atomFeed {
+	self := absoluteURL(baseURL, outputURL(f.Atom))
+
+	// Atom requires updated; a feed without dated items uses the Unix epoch.
+	updated := feedUpdated(items)
+	if updated.IsZero() {
+		updated = time.Unix(0, 0).UTC()
+	}
+
+	author := f.Author
+	if author == "" {
+		author = f.Title
+	}
+
+	feed := atomFeed{
+		XMLNS:   "http://www.w3.org/2005/Atom",
+		Title:   f.Title,
+		ID:      self,
+		Updated: updated.Format(time.RFC3339),
+		Author:  atomPerson{Name: author},
+		Links: []atomLink{
+			{Href: absoluteURL(baseURL, "/"), Rel: "alternate", Type: "text/html"},
+			{Href: self, Rel: "self", Type: "application/atom+xml"},
+		},
+	}
+
+	for _, item := range items {
+		entryUpdated := item.Date
+		if entryUpdated.IsZero() {
+			entryUpdated = updated
+		}
+
+		entry := atomEntry{
+			Title:   item.Title,
+			ID:      item.URL,
+			Link:    atomLink{Href: item.URL, Rel: "alternate", Type: "text/html"},
+			Updated: entryUpdated.Format(time.RFC3339),
+		}
+
+		if item.Summary != "" {
+			entry.Summary = &atomText{Type: "html", Body: item.Summary}
+		}
+
+		if item.Content != "" {
+			entry.Content = &atomText{Type: "html", Body: item.Content}
+		}
+
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	return feed
+}
+
+// writeFeed writes the RSS and/or Atom files of a feed and returns the
+// written outputs.
+func writeFeed(outputDir string, f FeedConfig, baseURL string, items []feedItem) ([]string, error) {
+	var written []string
+
+	if f.RSS != "" {
+		if err := writeXML(filepath.Join(outputDir, f.RSS), newRSSFeed(f, baseURL, items)); err != nil {
+			return nil, err
+		}
+
+		written = append(written, f.RSS)
+	}
+
+	if f.Atom != "" {
+		if err := writeXML(filepath.Join(outputDir, f.Atom), newAtomFeed(f, baseURL, items)); err != nil {
+			return nil, err
+		}
+
+		written = append(written, f.Atom)
+	}
+
+	return written, nil
+}
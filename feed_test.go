@@ -0,0 +1,193 @@
+package ssssg
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFeedPages(t *testing.T) {
+	t.Parallel()
+
+	pages := []*PageInfo{
+		{Output: "blog/index.html"},
+		{Output: "blog/first/index.html", Data: map[string]any{"tags": []any{"go", "release"}}},
+		{Output: "blog/second/index.html", Data: map[string]any{"tags": []any{"news"}}},
+		{Output: "about/index.html", Data: map[string]any{"tags": []any{"go"}}},
+	}
+	collections := map[string][]*PageInfo{"posts": pages[1:3]}
+
+	outputs := func(selected []*PageInfo) string {
+		names := make([]string, 0, len(selected))
+		for _, p := range selected {
+			names = append(names, p.Output)
+		}
+
+		return strings.Join(names, ",")
+	}
+
+	tests := []struct {
+		name string
+		feed FeedConfig
+		want string
+	}{
+		{"glob", FeedConfig{Pages: "blog/*/index.html"}, "blog/first/index.html,blog/second/index.html"},
+		{"section", FeedConfig{Section: "blog"}, "blog/first/index.html,blog/second/index.html"},
+		{"tag", FeedConfig{Tag: "go"}, "blog/first/index.html,about/index.html"},
+		{"section and tag", FeedConfig{Section: "blog", Tag: "go"}, "blog/first/index.html"},
+		{"collection", FeedConfig{Collection: "posts", Tag: "news"}, "blog/second/index.html"},
+	}
+
+	for _, tt := range tests {
+		if got := outputs(feedPages(tt.feed, pages, collections)); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFeedItems(t *testing.T) {
+	t.Parallel()
+
+	pages := []*PageInfo{
+		{URL: "/undated/", Data: map[string]any{"title": "Undated"}},
+		{URL: "/old/", Data: map[string]any{"title": "Old", "date": "2024-01-02"}},
+		{URL: "/new/", Data: map[string]any{"title": "New", "published": "2024-03-04T10:00:00Z", "body": "<p>hi</p>"}},
+	}
+
+	f := FeedConfig{Fields: map[string]string{"date": "published", "content": "body"}, Limit: 2}
+
+	items := feedItems(f, "https://example.com", pages)
+	if len(items) != 2 {
+		t.Fatalf("len(items) = %d, want 2", len(items))
+	}
+
+	if items[0].Title != "New" || items[0].URL != "https://example.com/new/" || items[0].Content != "<p>hi</p>" {
+		t.Errorf("items[0] = %+v", items[0])
+	}
+
+	if !items[0].Date.Equal(time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("items[0].Date = %v", items[0].Date)
+	}
+
+	// "date" is remapped to "published", so "Old" is undated too
+	if items[1].Title != "Undated" {
+		t.Errorf("items[1] = %+v, want Undated", items[1])
+	}
+}
+
+func TestNewFeed_Undated(t *testing.T) {
+	t.Parallel()
+
+	f := FeedConfig{Title: "Notes", RSS: "feed.xml", Atom: "atom.xml"}
+	items := []feedItem{{Title: "Undated", URL: "https://example.com/undated/"}}
+
+	if rss := newRSSFeed(f, "https://example.com", items); rss.Channel.LastBuildDate != "" {
+		t.Errorf("rss lastBuildDate = %q, want none", rss.Channel.LastBuildDate)
+	}
+
+	atom := newAtomFeed(f, "https://example.com", items)
+	if atom.Updated != "1970-01-01T00:00:00Z" || atom.Entries[0].Updated != atom.Updated {
+		t.Errorf("atom updated = %q, entry updated = %q", atom.Updated, atom.Entries[0].Updated)
+	}
+
+	if atom.Author.Name != "Notes" {
+		t.Errorf("atom author = %q, want the title", atom.Author.Name)
+	}
+}
+
+func TestBuild_WithFeeds(t *testing.T) {
+	t.Parallel()
+
+	yaml := `
+global:
+  base_url: "https://example.com"
+
+content:
+  template: "post.html"
+
+feeds:
+  - name: blog
+    title: "My Blog"
+    author: "Alice"
+    section: "blog"
+    rss: "blog/feed.xml"
+    atom: "blog/atom.xml"
+`
+
+	dir := setupProject(t, yaml)
+
+	tmpl := `{{ with .Feeds.blog }}<link rel="alternate" href="{{ .RSSURL }}" title="{{ .Title }}">{{ end }}{{ .Content }}`
+	if err := os.WriteFile(filepath.Join(dir, "templates", "post.html"), []byte(tmpl), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	contentDir := filepath.Join(dir, "content")
+	writeContentFile(t, contentDir, "blog/first.md", "---\ntitle: First\ndate: 2024-01-01\nsummary: The first post\n---\n# Hello\n")
+	writeContentFile(t, contentDir, "blog/second.md", "---\ntitle: Second\ndate: 2024-02-01\n---\nBody\n")
+	writeContentFile(t, contentDir, "about.md", "---\ntitle: About\n---\nAbout\n")
+
+	err := Build(t.Context(), BuildOptions{
+		ConfigPath: filepath.Join(dir, "site.yaml"),
+		Timeout:    10 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	page, err := os.ReadFile(filepath.Join(dir, "public", "about", "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(page), `<link rel="alternate" href="/blog/feed.xml" title="My Blog">`) {
+		t.Errorf("page missing feed link: %s", page)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "public", "blog", "feed.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var rss rssFeed
+	if err := xml.Unmarshal(data, &rss); err != nil {
+		t.Fatalf("parse rss: %v\n%s", err, data)
+	}
+
+	if len(rss.Channel.Items) != 2 {
+		t.Fatalf("rss items = %d, want 2\n%s", len(rss.Channel.Items), data)
+	}
+
+	first := rss.Channel.Items[0]
+	if first.Title != "Second" || first.Link != "https://example.com/blog/second/" {
+		t.Errorf("rss items[0] = %+v", first)
+	}
+
+	if !strings.Contains(string(data), "<description>The first post</description>") {
+		t.Errorf("rss missing summary:\n%s", data)
+	}
+
+	data, err = os.ReadFile(filepath.Join(dir, "public", "blog", "atom.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var atom atomFeed
+	if err := xml.Unmarshal(data, &atom); err != nil {
+		t.Fatalf("parse atom: %v\n%s", err, data)
+	}
+
+	if len(atom.Entries) != 2 || atom.Updated != "2024-02-01T00:00:00Z" {
+		t.Errorf("atom = %d entries, updated %s\n%s", len(atom.Entries), atom.Updated, data)
+	}
+
+	if atom.Author.Name != "Alice" {
+		t.Errorf("atom author = %q, want Alice", atom.Author.Name)
+	}
+
+	if atom.Entries[1].Content == nil || !strings.Contains(atom.Entries[1].Content.Body, "<h1") {
+		t.Errorf("atom entry content = %+v", atom.Entries[1].Content)
+	}
+}
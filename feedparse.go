@@ -0,0 +1,168 @@
+package ssssg
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+var errFeedUnknown = errors.New("not an RSS or Atom feed")
+
+// Parsed feeds are decoded from these structs. Fields without a namespace
+// match elements of any namespace, so <dc:creator> and <dc:date> are read
+// too.
+type rssDocument struct {
+	Channel struct {
+		Items []struct {
+			Title       string `xml:"title"`
+			Link        string `xml:"link"`
+			PubDate     string `xml:"pubDate"`
+			Date        string `xml:"date"`
+			Description string `xml:"description"`
+			Author      string `xml:"author"`
+			Creator     string `xml:"creator"`
+		} `xml:"item"`
+	} `xml:"channel"`
+}
+
+type atomDocument struct {
+	Entries []struct {
+		Title string `xml:"title"`
+		Links []struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+		} `xml:"link"`
+		Published string `xml:"published"`
+		Updated   string `xml:"updated"`
+		Summary   string `xml:"summary"`
+		Content   string `xml:"content"`
+		Authors   []struct {
+			Name string `xml:"name"`
+		} `xml:"author"`
+	} `xml:"entry"`
+}
+
+// decodeFeed parses an RSS 2.0 or Atom document into a list of items with
+// the keys title, link, date, summary and author. Dates are normalized to
+// RFC 3339 when they can be parsed and kept as they are otherwise.
+func decodeFeed(content []byte) (any, error) {
+	root, err := xmlRoot(content)
+	if err != nil {
+		return nil, fmt.Errorf("decode feed: %w", err)
+	}
+
+	switch root {
+	case "rss":
+		var doc rssDocument
+		if err := unmarshalXML(content, &doc); err != nil {
+			return nil, fmt.Errorf("decode rss: %w", err)
+		}
+
+		items := make([]any, 0, len(doc.Channel.Items))
+		for _, item := range doc.Channel.Items {
+			items = append(items, feedEntry(
+				item.Title,
+				item.Link,
+				firstNonEmpty(item.PubDate, item.Date),
+				item.Description,
+				firstNonEmpty(item.Author, item.Creator),
+			))
+		}
+
+		return items, nil
+	case "feed":
+		var doc atomDocument
+		if err := unmarshalXML(content, &doc); err != nil {
+			return nil, fmt.Errorf("decode atom: %w", err)
+		}
+
+		items := make([]any, 0, len(doc.Entries))
+		for _, entry := range doc.Entries {
+			link := ""
+			for _, l := range entry.Links {
+				if l.Rel == "" || l.Rel == "alternate" {
+					link = l.Href
+
+					break
+				}
+			}
+
+			author := ""
+			if len(entry.Authors) > 0 {
+				author = entry.Authors[0].Name
+			}
+
+			items = append(items, feedEntry(
+				entry.Title,
+				link,
+				firstNonEmpty(entry.Published, entry.Updated),
+				firstNonEmpty(entry.Summary, entry.Content),
+				author,
+			))
+		}
+
+		return items, nil
+	}
+
+	return nil, fmt.Errorf("%w: root element <%s>", errFeedUnknown, root)
+}
+
+func feedEntry(title, link, date, summary, author string) map[string]any {
+	date = strings.TrimSpace(date)
+	if t := feedDate(date); !t.IsZero() {
+		date = t.Format(time.RFC3339)
+	}
+
+	return map[string]any{
+		"title":   strings.TrimSpace(title),
+		"link":    strings.TrimSpace(link),
+		"date":    date,
+		"summary": strings.TrimSpace(summary),
+		"author":  strings.TrimSpace(author),
+	}
+}
+
+// newXMLDecoder returns a decoder for fetched content. Fetched content is
+// already UTF-8, whatever encoding its XML declaration names.
+func newXMLDecoder(content []byte) *xml.Decoder {
+	dec := xml.NewDecoder(bytes.NewReader(content))
+	dec.CharsetReader = func(_ string, r io.Reader) (io.Reader, error) {
+		return r, nil
+	}
+
+	return dec
+}
+
+func unmarshalXML(content []byte, v any) error {
+	return newXMLDecoder(content).Decode(v)
+}
+
+// xmlRoot returns the local name of the root element of an XML document.
+func xmlRoot(content []byte) (string, error) {
+	dec := newXMLDecoder(content)
+
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return "", err
+		}
+
+		if start, ok := tok.(xml.StartElement); ok {
+			return start.Name.Local, nil
+		}
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+
+	return ""
+}
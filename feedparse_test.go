@@ -0,0 +1,153 @@
+package ssssg
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+const testRSS = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:dc="http://purl.org/dc/elements/1.1/">
+  <channel>
+    <title>Engineering</title>
+    <item>
+      <title>Faster builds</title>
+      <link>https://blog.example.com/faster-builds</link>
+      <pubDate>Tue, 2 Jan 2024 10:00:00 +0900</pubDate>
+      <description><![CDATA[<p>How we cut build times.</p>]]></description>
+      <dc:creator>Alice</dc:creator>
+    </item>
+    <item>
+      <title>Undated</title>
+      <link>https://blog.example.com/undated</link>
+      <pubDate>sometime</pubDate>
+    </item>
+  </channel>
+</rss>`
+
+const testAtom = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Engineering</title>
+  <entry>
+    <title>Hello Atom</title>
+    <link rel="self" href="https://blog.example.com/hello.atom"/>
+    <link href="https://blog.example.com/hello"/>
+    <updated>2024-03-01T12:00:00Z</updated>
+    <published>2024-02-28T09:30:00+01:00</published>
+    <content type="html">&lt;p&gt;Body&lt;/p&gt;</content>
+    <author><name>Bob</name></author>
+  </entry>
+</feed>`
+
+func TestDecodeFeed(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+		want    any
+	}{
+		{
+			name:    "rss",
+			content: testRSS,
+			want: []any{
+				map[string]any{
+					"title":   "Faster builds",
+					"link":    "https://blog.example.com/faster-builds",
+					"date":    "2024-01-02T10:00:00+09:00",
+					"summary": "<p>How we cut build times.</p>",
+					"author":  "Alice",
+				},
+				map[string]any{
+					"title":   "Undated",
+					"link":    "https://blog.example.com/undated",
+					"date":    "sometime",
+					"summary": "",
+					"author":  "",
+				},
+			},
+		},
+		{
+			name:    "atom",
+			content: testAtom,
+			want: []any{
+				map[string]any{
+					"title":   "Hello Atom",
+					"link":    "https://blog.example.com/hello",
+					"date":    "2024-02-28T09:30:00+01:00",
+					"summary": "<p>Body</p>",
+					"author":  "Bob",
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := decodeData(FormatFeed, []byte(tt.content))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeFeed_Unknown(t *testing.T) {
+	t.Parallel()
+
+	if _, err := decodeFeed([]byte(`<html><body></body></html>`)); !errors.Is(err, errFeedUnknown) {
+		t.Errorf("expected errFeedUnknown, got %v", err)
+	}
+
+	if _, err := decodeFeed([]byte(`not xml`)); err == nil {
+		t.Error("expected error for invalid XML")
+	}
+}
+
+func TestFetcher_ResolveFeedAuto(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/rss+xml")
+		_, _ = w.Write([]byte(testRSS))
+	}))
+	defer srv.Close()
+
+	f := NewFetcher(t.TempDir(), srv.Client())
+
+	got, err := f.Resolve(context.Background(), FetchEntry{URL: srv.URL + "/feed", Format: FormatAuto})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	items, ok := got.([]any)
+	if !ok || len(items) != 2 {
+		t.Fatalf("expected 2 items, got %#v", got)
+	}
+}
+
+func TestDecodeFeed_ConvertedEncoding(t *testing.T) {
+	t.Parallel()
+
+	// Content is converted to UTF-8 before decoding; the declaration is stale
+	content := `<?xml version="1.0" encoding="Shift_JIS"?><rss><channel><item><title>お知らせ</title></item></channel></rss>`
+
+	got, err := decodeFeed([]byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	items, ok := got.([]any)
+	if !ok || len(items) != 1 || items[0].(map[string]any)["title"] != "お知らせ" {
+		t.Errorf("got %#v", got)
+	}
+}
@@ -0,0 +1,109 @@
+package ssssg
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// fetchCacheEntry is a remote response stored on disk.
+type fetchCacheEntry struct {
+	URL       string      `json:"url"`
+	Header    http.Header `json:"header"`
+	Body      []byte      `json:"body"`
+	FetchedAt time.Time   `json:"fetched_at"`
+}
+
+func (e *fetchCacheEntry) result() fetchResult {
+	return fetchResult{
+		body:        string(e.Body),
+		contentType: e.Header.Get("Content-Type"),
+		header:      e.Header,
+	}
+}
+
+// fresh reports whether the entry is younger than ttl. A zero ttl is never
+// fresh, so the source is fetched again on every build.
+func (e *fetchCacheEntry) fresh(ttl time.Duration) bool {
+	return ttl > 0 && time.Since(e.FetchedAt) < ttl
+}
+
+// fetchCache persists remote responses in a directory, one JSON file per
+// key. A nil *fetchCache stores nothing and never hits.
+type fetchCache struct {
+	dir string
+}
+
+func (c *fetchCache) path(key string) string {
+	return filepath.Join(c.dir, hashStrings(key)+".json")
+}
+
+// dataPath is where the body of a download is kept. Its headers are stored
+// under the same key like any other response.
+func (c *fetchCache) dataPath(key string) string {
+	return filepath.Join(c.dir, hashStrings(key)+".data")
+}
+
+func (c *fetchCache) load(key string) (*fetchCacheEntry, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+
+	var e fetchCacheEntry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, false
+	}
+
+	return &e, true
+}
+
+// store writes the entry through a temporary file so that a concurrent
+// build never reads a partial entry.
+func (c *fetchCache) store(key string, e *fetchCacheEntry) error {
+	if c == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("encode fetch cache entry: %w", err)
+	}
+
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return fmt.Errorf("create fetch cache dir: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(c.dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("create fetch cache entry: %w", err)
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+
+		return fmt.Errorf("write fetch cache entry: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+
+		return fmt.Errorf("write fetch cache entry: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), c.path(key)); err != nil {
+		os.Remove(tmp.Name())
+
+		return fmt.Errorf("write fetch cache entry: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,157 @@
+package ssssg
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFetcher_DiskCacheTTL(t *testing.T) {
+	t.Parallel()
+
+	var hits atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"name": "ssssg"}`))
+	}))
+	defer srv.Close()
+
+	cacheDir := t.TempDir()
+	entry := FetchEntry{URL: srv.URL, Format: FormatAuto, TTL: time.Hour}
+
+	// Every Fetcher starts with an empty memory cache, like a new build
+	for range 2 {
+		f := NewFetcher("", srv.Client(), WithCacheDir(cacheDir))
+
+		v, err := f.Resolve(t.Context(), entry)
+		if err != nil {
+			t.Fatalf("Resolve failed: %v", err)
+		}
+
+		// The cached Content-Type still drives format detection
+		if m, ok := v.(map[string]any); !ok || m["name"] != "ssssg" {
+			t.Fatalf("Resolve = %#v", v)
+		}
+	}
+
+	if got := hits.Load(); got != 1 {
+		t.Errorf("server hits = %d, want 1 within TTL", got)
+	}
+
+	// Without a TTL the source is fetched again
+	f := NewFetcher("", srv.Client(), WithCacheDir(cacheDir))
+	if _, err := f.Fetch(t.Context(), srv.URL); err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+
+	if got := hits.Load(); got != 2 {
+		t.Errorf("server hits = %d, want 2 without TTL", got)
+	}
+}
+
+func TestFetcher_DiskCacheEnv(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	defer srv.Close()
+
+	cacheDir := t.TempDir()
+	entry := FetchEntry{
+		URL:     srv.URL,
+		Headers: map[string]string{"Authorization": "${SSSSG_TEST_TOKEN}"},
+		TTL:     time.Hour,
+	}
+
+	// A build with another credential must not get the cached response
+	for _, token := range []string{"tenant-a", "tenant-b"} {
+		t.Setenv("SSSSG_TEST_TOKEN", token)
+
+		v, err := NewFetcher("", srv.Client(), WithCacheDir(cacheDir)).Resolve(t.Context(), entry)
+		if err != nil {
+			t.Fatalf("Resolve failed: %v", err)
+		}
+
+		if v != token {
+			t.Errorf("Resolve = %#v, want %q", v, token)
+		}
+	}
+}
+
+func TestFetcher_Offline(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("cached body"))
+	}))
+
+	cacheDir := t.TempDir()
+	url := srv.URL
+
+	if _, err := NewFetcher("", srv.Client(), WithCacheDir(cacheDir)).Fetch(t.Context(), url); err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+
+	srv.Close()
+
+	f := NewFetcher("", nil, WithCacheDir(cacheDir), WithOffline(true))
+
+	content, err := f.Fetch(t.Context(), url)
+	if err != nil {
+		t.Fatalf("offline Fetch failed: %v", err)
+	}
+
+	if content != "cached body" {
+		t.Errorf("content = %q, want %q", content, "cached body")
+	}
+
+	_, err = f.Fetch(t.Context(), url+"/missing")
+	if !errors.Is(err, errOfflineCacheMiss) {
+		t.Errorf("err = %v, want errOfflineCacheMiss", err)
+	}
+}
+
+func TestFetcher_ConditionalRequest(t *testing.T) {
+	t.Parallel()
+
+	var full, notModified atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` && r.Header.Get("If-Modified-Since") == "Mon, 01 Jan 2024 00:00:00 GMT" {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+
+			return
+		}
+
+		full.Add(1)
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", "Mon, 01 Jan 2024 00:00:00 GMT")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[1, 2, 3]`))
+	}))
+	defer srv.Close()
+
+	cacheDir := t.TempDir()
+
+	for range 3 {
+		f := NewFetcher("", srv.Client(), WithCacheDir(cacheDir))
+
+		v, err := f.Resolve(t.Context(), FetchEntry{URL: srv.URL, Format: FormatAuto})
+		if err != nil {
+			t.Fatalf("Resolve failed: %v", err)
+		}
+
+		if items, ok := v.([]any); !ok || len(items) != 3 {
+			t.Fatalf("Resolve = %#v", v)
+		}
+	}
+
+	if full.Load() != 1 || notModified.Load() != 2 {
+		t.Errorf("full = %d, not modified = %d, want 1 and 2", full.Load(), notModified.Load())
+	}
+}
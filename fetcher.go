@@ -1,15 +1,15 @@
 package ssssg
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
-	"io"
 	"net/http"
+	neturl "net/url"
 	"os"
-	"path/filepath"
-	"strings"
 	"sync"
+	"time"
 
 	"golang.org/x/sync/errgroup"
 	"golang.org/x/sync/singleflight"
@@ -18,97 +18,499 @@ import (
 var (
 	errHTTPStatus       = errors.New("unexpected HTTP status")
 	errUnexpectedResult = errors.New("unexpected result type")
+	errOfflineCacheMiss = errors.New("not in fetch cache (offline mode)")
 )
 
 type Fetcher struct {
-	baseDir string
-	client  *http.Client
-	mu      sync.Mutex
-	cache   map[string]string
-	group   singleflight.Group
+	baseDir        string
+	client         *http.Client
+	disk           *fetchCache
+	offline        bool
+	retry          RetryPolicy
+	requestTimeout time.Duration
+	outputDir      string // destination of downloads
+	maxBody        int64  // body size limit, defaultMaxBodySize if zero
+	logf           func(format string, args ...any)
+	mu             sync.Mutex
+	cache          map[string]fetchResult
+	failed         map[string]error    // sources that failed, not retried within a build
+	warned         map[string]struct{} // warnings already logged
+	group          singleflight.Group
 }
 
-func NewFetcher(baseDir string, client *http.Client) *Fetcher {
+// FetcherOption configures optional Fetcher behavior.
+type FetcherOption func(*Fetcher)
+
+// WithCacheDir persists remote responses in dir so that later builds can
+// reuse them within the TTL of each source, or entirely in offline mode.
+func WithCacheDir(dir string) FetcherOption {
+	return func(f *Fetcher) {
+		f.disk = &fetchCache{dir: dir}
+	}
+}
+
+// WithOffline serves remote sources exclusively from the disk cache. A
+// source missing from the cache is an error.
+func WithOffline(offline bool) FetcherOption {
+	return func(f *Fetcher) {
+		f.offline = offline
+	}
+}
+
+// WithRetry retries failed HTTP fetches according to policy.
+func WithRetry(policy RetryPolicy) FetcherOption {
+	return func(f *Fetcher) {
+		f.retry = policy
+	}
+}
+
+// WithRequestTimeout bounds every single HTTP request, including reading the
+// response body. Retries get a fresh timeout. Zero means no limit besides
+// the context.
+func WithRequestTimeout(d time.Duration) FetcherOption {
+	return func(f *Fetcher) {
+		f.requestTimeout = d
+	}
+}
+
+// WithMaxBodySize fails fetches whose body is larger than n bytes, unless
+// the entry sets its own limit. Zero keeps the default of 100 MiB.
+func WithMaxBodySize(n int64) FetcherOption {
+	return func(f *Fetcher) {
+		f.maxBody = n
+	}
+}
+
+// WithOutputDir sets the directory downloads are written to.
+func WithOutputDir(dir string) FetcherOption {
+	return func(f *Fetcher) {
+		f.outputDir = dir
+	}
+}
+
+// WithLogf reports retries and the use of fallbacks through logf.
+func WithLogf(logf func(format string, args ...any)) FetcherOption {
+	return func(f *Fetcher) {
+		f.logf = logf
+	}
+}
+
+// fetchResult is the raw content of a source and its Content-Type, if known.
+type fetchResult struct {
+	body        string
+	contentType string
+	header      http.Header     // response headers of remote sources
+	file        *StaticFileInfo // metadata of a downloaded file
+}
+
+func NewFetcher(baseDir string, client *http.Client, opts ...FetcherOption) *Fetcher {
 	if client == nil {
 		client = http.DefaultClient
 	}
 
-	return &Fetcher{
+	f := &Fetcher{
 		baseDir: baseDir,
 		client:  client,
-		cache:   make(map[string]string),
+		logf:    func(string, ...any) {},
+		cache:   make(map[string]fetchResult),
+		failed:  make(map[string]error),
+		warned:  make(map[string]struct{}),
 	}
+
+	for _, opt := range opts {
+		opt(f)
+	}
+
+	return f
 }
 
+// Fetch returns the raw content of source, a URL or a path relative to the
+// base directory. Results are cached for the lifetime of the Fetcher.
 func (f *Fetcher) Fetch(ctx context.Context, source string) (string, error) {
+	res, err := f.fetch(ctx, FetchEntry{URL: source})
+	if err != nil {
+		return "", err
+	}
+
+	return res.body, nil
+}
+
+// Prefetch fetches an entry without decoding it, so that later Resolve calls
+// are served from memory. Entries with fallbacks or a default are resolved,
+// so that the fallbacks are fetched and warned about once.
+func (f *Fetcher) Prefetch(ctx context.Context, entry FetchEntry) error {
+	// Followed pages are requested exactly as resolving them later will
+	if entry.hasFallback() || entry.Follow != nil {
+		_, err := f.Resolve(ctx, entry)
+
+		return err
+	}
+
+	files, _, ok, err := f.globFiles(entry.URL)
+	if err != nil {
+		return fmt.Errorf("fetch %s: %w", entry.describe(), err)
+	}
+
+	if !ok {
+		_, err = f.fetch(ctx, entry)
+
+		return err
+	}
+
+	for _, file := range files {
+		if _, err := f.fetch(ctx, FetchEntry{URL: file, Encoding: entry.Encoding, MaxBodySize: entry.MaxBodySize}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// resolve fetches an entry and decodes it according to its format, without
+// falling back to other sources.
+func (f *Fetcher) resolve(ctx context.Context, entry FetchEntry) (any, error) {
+	if entry.Follow != nil {
+		return f.resolveFollow(ctx, entry)
+	}
+
+	files, base, ok, err := f.globFiles(entry.URL)
+	if err != nil {
+		return nil, fmt.Errorf("fetch %s: %w", entry.describe(), err)
+	}
+
+	if ok {
+		return f.resolveFiles(ctx, entry, files, base)
+	}
+
+	res, err := f.fetch(ctx, entry)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.file != nil {
+		return *res.file, nil
+	}
+
+	if entry.Type == FetchTypeGraphQL {
+		v, err := decodeGraphQL([]byte(res.body))
+		if err != nil {
+			return nil, fmt.Errorf("fetch %s: %w", entry.describe(), err)
+		}
+
+		return v, nil
+	}
+
+	if entry.Select != "" {
+		v, err := selectHTML(res.body, entry.Select, entry.Extract)
+		if err != nil {
+			return nil, fmt.Errorf("fetch %s: %w", entry.describe(), err)
+		}
+
+		return v, nil
+	}
+
+	format := entry.Format
+	if format == FormatAuto {
+		format = detectFormat(entry.URL, res.contentType, []byte(res.body))
+	}
+
+	v, err := decodeData(format, []byte(res.body))
+	if err != nil {
+		return nil, fmt.Errorf("fetch %s: %w", entry.describe(), err)
+	}
+
+	return v, nil
+}
+
+func (f *Fetcher) fetch(ctx context.Context, entry FetchEntry) (fetchResult, error) {
+	if entry.Type == FetchTypeGraphQL {
+		req, err := f.graphQLEntry(entry)
+		if err != nil {
+			return fetchResult{}, fmt.Errorf("fetch %s: %w", entry.describe(), err)
+		}
+
+		entry = req
+	}
+
+	source := entry.URL
+	key := entry.cacheKey()
+
 	f.mu.Lock()
-	if v, ok := f.cache[source]; ok {
+	if v, ok := f.cache[key]; ok {
 		f.mu.Unlock()
 
 		return v, nil
 	}
+
+	if err, ok := f.failed[key]; ok {
+		f.mu.Unlock()
+
+		return fetchResult{}, err
+	}
 	f.mu.Unlock()
 
-	v, err, _ := f.group.Do(source, func() (any, error) {
-		var content string
+	v, err, _ := f.group.Do(key, func() (any, error) {
+		var res fetchResult
 		var fetchErr error
 
-		if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
-			content, fetchErr = f.fetchHTTP(ctx, source)
-		} else {
-			content, fetchErr = f.fetchFile(source)
+		switch {
+		case entry.Exec != nil:
+			res.body, fetchErr = f.fetchExec(ctx, *entry.Exec, f.maxBodySize(entry))
+		case entry.Download != "":
+			res, fetchErr = f.download(ctx, entry)
+		case isRemote(source):
+			res, fetchErr = f.fetchRemote(ctx, entry)
+		default:
+			res.body, fetchErr = f.fetchFile(source, f.maxBodySize(entry))
+		}
+
+		// Remote responses are converted before they are cached on disk
+		if fetchErr == nil && !isRemote(source) {
+			res.body, fetchErr = toUTF8(res.body, "", entry.Encoding)
 		}
 
 		if fetchErr != nil {
-			return "", fetchErr
+			return fetchResult{}, fetchErr
 		}
 
 		f.mu.Lock()
-		f.cache[source] = content
+		f.cache[key] = res
 		f.mu.Unlock()
 
-		return content, nil
+		return res, nil
 	})
 	if err != nil {
-		return "", fmt.Errorf("fetch %s: %w", source, err)
+		err = fmt.Errorf("fetch %s: %w", entry.describe(), err)
+
+		if ctx.Err() == nil {
+			f.mu.Lock()
+			f.failed[key] = err
+			f.mu.Unlock()
+		}
+
+		return fetchResult{}, err
 	}
 
-	content, ok := v.(string)
+	res, ok := v.(fetchResult)
 	if !ok {
-		return "", fmt.Errorf("fetch %s: %w", source, errUnexpectedResult)
+		return fetchResult{}, fmt.Errorf("fetch %s: %w", entry.describe(), errUnexpectedResult)
 	}
 
-	return content, nil
+	return res, nil
 }
 
-func (f *Fetcher) fetchHTTP(ctx context.Context, url string) (string, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+// fetchRemote serves a remote source from the disk cache when offline or
+// while the cached response is within the entry's TTL, and fetches and
+// caches it otherwise. A stale cached response is revalidated with a
+// conditional request.
+func (f *Fetcher) fetchRemote(ctx context.Context, entry FetchEntry) (fetchResult, error) {
+	key := entry.cacheKey()
+	cached, ok := f.disk.load(key)
+
+	if f.offline {
+		if !ok {
+			return fetchResult{}, errOfflineCacheMiss
+		}
+
+		return cached.result(), nil
+	}
+
+	if ok && cached.fresh(entry.TTL) {
+		return cached.result(), nil
+	}
+
+	res, err := f.fetchHTTP(ctx, entry, cached)
 	if err != nil {
-		return "", fmt.Errorf("create request for %s: %w", url, err)
+		return fetchResult{}, err
 	}
 
-	resp, err := f.client.Do(req)
+	err = f.disk.store(key, &fetchCacheEntry{
+		URL:       redactURL(entry.URL),
+		Header:    res.header,
+		Body:      []byte(res.body),
+		FetchedAt: time.Now(),
+	})
+	if err != nil {
+		return fetchResult{}, err
+	}
+
+	return res, nil
+}
+
+// fetchHTTP requests a remote entry, retrying failed attempts according to
+// the retry policy.
+func (f *Fetcher) fetchHTTP(ctx context.Context, entry FetchEntry, cached *fetchCacheEntry) (fetchResult, error) {
+	var res fetchResult
+
+	err := f.withRetry(ctx, entry, func() error {
+		var err error
+
+		res, err = f.fetchHTTPOnce(ctx, entry, cached)
+
+		return err
+	})
+
+	return res, err
+}
+
+// withRetry calls attempt until it succeeds, fails with an error that is not
+// retryable or runs out of retries.
+func (f *Fetcher) withRetry(ctx context.Context, entry FetchEntry, attempt func() error) error {
+	url := entry.describe()
+
+	for n := 0; ; n++ {
+		err := attempt()
+		if err == nil {
+			return nil
+		}
+
+		retryable, retryAfter := f.retry.retryable(err)
+		if !retryable || n >= f.retry.Retries || ctx.Err() != nil {
+			return err
+		}
+
+		// Waiting past the build deadline would only end in a timeout
+		delay := f.retry.delay(n, retryAfter)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return err
+		}
+
+		f.logf("  Retrying %s in %s (%d/%d): %v", url, delay, n+1, f.retry.Retries, err)
+
+		timer := time.NewTimer(delay)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return fmt.Errorf("fetch %s: %w", url, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// fetchHTTPOnce performs a single request.
+func (f *Fetcher) fetchHTTPOnce(ctx context.Context, entry FetchEntry, cached *fetchCacheEntry) (fetchResult, error) {
+	ctx, cancel := f.requestContext(ctx)
+	defer cancel()
+
+	url := entry.describe()
+
+	resp, err := f.send(ctx, entry, cached)
 	if err != nil {
-		return "", fmt.Errorf("fetch %s: %w", url, err)
+		return fetchResult{}, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("fetch %s: %w: %d", url, errHTTPStatus, resp.StatusCode)
+	if resp.StatusCode == http.StatusNotModified {
+		res := cached.result()
+		res.header = revalidatedHeader(cached.Header, resp.Header)
+
+		return res, nil
+	}
+
+	var body bytes.Buffer
+	if err := copyLimited(&body, resp.Body, f.maxBodySize(entry)); err != nil {
+		return fetchResult{}, fmt.Errorf("read response from %s: %w", url, err)
+	}
+
+	text, err := toUTF8(body.String(), resp.Header.Get("Content-Type"), entry.Encoding)
+	if err != nil {
+		return fetchResult{}, fmt.Errorf("fetch %s: %w", url, err)
+	}
+
+	return fetchResult{
+		body:        text,
+		contentType: resp.Header.Get("Content-Type"),
+		header:      resp.Header,
+	}, nil
+}
+
+// requestContext bounds a single request by the request timeout, if any.
+func (f *Fetcher) requestContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if f.requestTimeout > 0 {
+		return context.WithTimeout(ctx, f.requestTimeout)
+	}
+
+	return context.WithCancel(ctx)
+}
+
+// send performs the request of a remote entry and returns a 200 OK
+// response, or a 304 Not Modified when cached is given. A 200 OK with a
+// Content-Length above the body size limit is an error. With a cached
+// response to a GET it sends If-None-Match and If-Modified-Since from the
+// cached ETag and Last-Modified. Errors name the redacted URL, never the
+// expanded request URL.
+func (f *Fetcher) send(ctx context.Context, entry FetchEntry, cached *fetchCacheEntry) (*http.Response, error) {
+	url := entry.describe()
+
+	req, err := entry.newRequest(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if cached != nil && req.Method == http.MethodGet {
+		if etag := cached.Header.Get("ETag"); etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+
+		if lastModified := cached.Header.Get("Last-Modified"); lastModified != "" {
+			req.Header.Set("If-Modified-Since", lastModified)
+		}
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	resp, err := f.client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("read response from %s: %w", url, err)
+		// *url.Error repeats the request URL, which may contain expanded secrets
+		var urlErr *neturl.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+
+		return nil, fmt.Errorf("fetch %s: %w", url, err)
 	}
 
-	return string(body), nil
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		return resp, nil
+	}
+
+	if resp.StatusCode == http.StatusOK {
+		if err := checkContentLength(resp.ContentLength, f.maxBodySize(entry)); err != nil {
+			resp.Body.Close()
+
+			return nil, fmt.Errorf("fetch %s: %w", url, err)
+		}
+
+		return resp, nil
+	}
+
+	resp.Body.Close()
+
+	return nil, fmt.Errorf("fetch %s: %w", url, &statusError{
+		code:       resp.StatusCode,
+		retryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	})
+}
+
+// revalidatedHeader returns the cached headers updated with the validators a
+// 304 response may carry.
+func revalidatedHeader(cached, notModified http.Header) http.Header {
+	header := cached.Clone()
+
+	for _, key := range []string{"ETag", "Last-Modified"} {
+		if v := notModified.Get(key); v != "" {
+			header.Set(key, v)
+		}
+	}
+
+	return header
 }
 
-func (f *Fetcher) fetchFile(path string) (string, error) {
-	absPath := path
-	if !filepath.IsAbs(path) {
-		absPath = filepath.Join(f.baseDir, path)
+func (f *Fetcher) fetchFile(path string, limit int64) (string, error) {
+	absPath := f.absPath(path)
+
+	if info, err := os.Stat(absPath); err == nil && info.Size() > limit {
+		return "", fmt.Errorf("read file %s: %w: %d > %d bytes", absPath, errBodyTooLarge, info.Size(), limit)
 	}
 
 	data, err := os.ReadFile(absPath)
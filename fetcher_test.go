@@ -165,3 +165,72 @@ func TestFetcher_FetchFile_NotFound(t *testing.T) {
 		t.Fatal("expected error for nonexistent file")
 	}
 }
+
+func TestFetcher_Resolve(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[{"name": "a"}, {"name": "b"}]`))
+	}))
+	defer srv.Close()
+
+	f := NewFetcher("", srv.Client())
+
+	// Raw stays the default
+	raw, err := f.Resolve(t.Context(), FetchEntry{URL: srv.URL})
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+
+	if _, ok := raw.(string); !ok {
+		t.Errorf("raw result = %T, want string", raw)
+	}
+
+	// Auto detects JSON from Content-Type
+	v, err := f.Resolve(t.Context(), FetchEntry{URL: srv.URL, Format: FormatAuto})
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+
+	items, ok := v.([]any)
+	if !ok || len(items) != 2 {
+		t.Fatalf("auto result = %#v, want 2 items", v)
+	}
+}
+
+func TestFetcher_ResolveFileByExtension(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "site.toml"), []byte("name = \"ssssg\""), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	f := NewFetcher(dir, nil)
+
+	v, err := f.Resolve(t.Context(), FetchEntry{URL: "site.toml", Format: FormatAuto})
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+
+	m, ok := v.(map[string]any)
+	if !ok || m["name"] != "ssssg" {
+		t.Errorf("result = %#v", v)
+	}
+}
+
+func TestFetcher_ResolveDecodeError(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "bad.json"), []byte("{oops"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	f := NewFetcher(dir, nil)
+
+	if _, err := f.Resolve(t.Context(), FetchEntry{URL: "bad.json", Format: FormatJSON}); err == nil {
+		t.Fatal("expected decode error")
+	}
+}
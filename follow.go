@@ -0,0 +1,339 @@
+package ssssg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// defaultMaxPages bounds how many pages a followed source fetches.
+const defaultMaxPages = 100
+
+var (
+	errFollowNotList    = errors.New("followed page items must be a list")
+	errFollowNextCursor = errors.New("follow must not have both next and cursor")
+	errFollowMaxPages   = errors.New("follow max_pages must not be negative")
+	errFollowOptions    = errors.New("follow requires an http(s) url and format json or auto")
+	errFollowNextValue  = errors.New("follow next must be a string")
+)
+
+// FollowConfig makes a fetch entry follow a paginated API and yield the
+// items of all pages as one list. The next page comes from the Link header
+// (rel="next") by default, from the URL at the JSON path Next, or from the
+// cursor at the JSON path Cursor sent as the CursorParam query parameter.
+// In site.yaml "follow: true" follows the Link header and "follow: false"
+// does not follow at all.
+type FollowConfig struct {
+	Next        string `yaml:"next"`         // JSON path of the next page URL, e.g. "links.next"
+	Cursor      string `yaml:"cursor"`       // JSON path of the next cursor, e.g. "meta.next_cursor"
+	CursorParam string `yaml:"cursor_param"` // query parameter for the cursor, defaults to "cursor"
+	Items       string `yaml:"items"`        // JSON path of the items, defaults to the whole page
+	MaxPages    int    `yaml:"max_pages"`    // defaults to 100
+
+	// disabled marks "follow: false"; FetchEntry drops the config.
+	disabled bool
+}
+
+func (c *FollowConfig) UnmarshalYAML(unmarshal func(any) error) error {
+	var enabled bool
+	if err := unmarshal(&enabled); err == nil {
+		*c = FollowConfig{disabled: !enabled}
+
+		return nil
+	}
+
+	type plain FollowConfig
+
+	var p plain
+	if err := unmarshal(&p); err != nil {
+		return err
+	}
+
+	*c = FollowConfig(p)
+
+	return nil
+}
+
+func validateFollow(entry FetchEntry) error {
+	c := entry.Follow
+
+	if !isRemote(entry.URL) || entry.Type != "" ||
+		(entry.Format != "" && entry.Format != FormatJSON && entry.Format != FormatAuto) {
+		return errFollowOptions
+	}
+
+	if c.Next != "" && c.Cursor != "" {
+		return errFollowNextCursor
+	}
+
+	if c.MaxPages < 0 {
+		return errFollowMaxPages
+	}
+
+	return nil
+}
+
+// resolveFollow fetches the pages of a followed entry one after another and
+// concatenates their items. Each page goes through the normal fetch path, so
+// caching and retries apply per page. It stops when there is no next page,
+// a page is empty, a page repeats, or max pages are reached.
+func (f *Fetcher) resolveFollow(ctx context.Context, entry FetchEntry) (any, error) {
+	c := entry.Follow
+
+	maxPages := c.MaxPages
+	if maxPages == 0 {
+		maxPages = defaultMaxPages
+	}
+
+	cursorParam := c.CursorParam
+	if cursorParam == "" {
+		cursorParam = "cursor"
+	}
+
+	items := []any{}
+	seen := make(map[string]struct{})
+	page := entry
+	page.Follow = nil
+
+	for n := 1; ; n++ {
+		seen[page.cacheKey()] = struct{}{}
+
+		res, err := f.fetch(ctx, page)
+		if err != nil {
+			return nil, err
+		}
+
+		v, err := decodeData(FormatJSON, []byte(res.body))
+		if err != nil {
+			return nil, fmt.Errorf("fetch %s: %w", page.describe(), err)
+		}
+
+		pageItems := v
+		if c.Items != "" {
+			pageItems, _ = lookupPath(v, c.Items)
+		}
+
+		list, ok := pageItems.([]any)
+		if !ok {
+			return nil, fmt.Errorf("fetch %s: %w, got %T", page.describe(), errFollowNotList, pageItems)
+		}
+
+		items = append(items, list...)
+
+		if len(list) == 0 {
+			return items, nil
+		}
+
+		next, err := nextPage(page, c, cursorParam, res, v)
+		if err != nil {
+			return nil, fmt.Errorf("fetch %s: %w", page.describe(), err)
+		}
+
+		if next == nil {
+			return items, nil
+		}
+
+		if _, ok := seen[next.cacheKey()]; ok {
+			return items, nil
+		}
+
+		if n >= maxPages {
+			f.logf("  Stopped following %s after %d page(s) (max_pages)", entry.describe(), n)
+
+			return items, nil
+		}
+
+		page = *next
+	}
+}
+
+// cursorValue formats a cursor for the query string. JSON numbers are
+// float64 and would print large values in exponent form.
+func cursorValue(v any) string {
+	if f, ok := v.(float64); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+
+	return fmt.Sprint(v)
+}
+
+// nextPage returns the entry of the page after page, or nil on the last page.
+func nextPage(page FetchEntry, c *FollowConfig, cursorParam string, res fetchResult, body any) (*FetchEntry, error) {
+	var nextURL string
+
+	switch {
+	case c.Cursor != "":
+		cursor, ok := lookupPath(body, c.Cursor)
+		if !ok || cursor == nil || cursor == "" {
+			return nil, nil //nolint:nilnil // no cursor means last page
+		}
+
+		u, err := url.Parse(page.URL)
+		if err != nil {
+			return nil, fmt.Errorf("parse url: %w", err)
+		}
+
+		q := u.Query()
+		q.Set(cursorParam, cursorValue(cursor))
+		u.RawQuery = q.Encode()
+
+		next := page
+		next.URL = u.String()
+		next.Query = withoutKey(page.Query, cursorParam)
+
+		return &next, nil
+	case c.Next != "":
+		v, ok := lookupPath(body, c.Next)
+		if !ok || v == nil || v == "" {
+			return nil, nil //nolint:nilnil // no next URL means last page
+		}
+
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("%w, got %T", errFollowNextValue, v)
+		}
+
+		nextURL = s
+	default:
+		nextURL = linkNext(res.header.Values("Link"))
+		if nextURL == "" {
+			return nil, nil //nolint:nilnil // no Link rel="next" means last page
+		}
+	}
+
+	base, err := url.Parse(page.URL)
+	if err != nil {
+		return nil, fmt.Errorf("parse url: %w", err)
+	}
+
+	ref, err := url.Parse(nextURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse next url: %w", err)
+	}
+
+	target := base.ResolveReference(ref)
+
+	// The next URL already carries the query of the page it points to
+	next := page
+	next.URL = target.String()
+	next.Query = nil
+
+	// Like redirects in net/http, headers such as Authorization are only
+	// sent to the host they were configured for.
+	if !strings.EqualFold(target.Host, base.Host) {
+		next.Headers = nil
+	}
+
+	return &next, nil
+}
+
+// linkNext returns the target of rel="next" in Link header values.
+func linkNext(values []string) string {
+	for _, value := range values {
+		for _, link := range splitLinks(value) {
+			target, params, found := strings.Cut(link, ";")
+			if !found {
+				continue
+			}
+
+			target = strings.TrimSpace(target)
+			if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+
+			for _, param := range strings.Split(params, ";") {
+				name, val, _ := strings.Cut(strings.TrimSpace(param), "=")
+				if !strings.EqualFold(name, "rel") {
+					continue
+				}
+
+				for _, rel := range strings.Fields(strings.Trim(val, `"`)) {
+					if strings.EqualFold(rel, "next") {
+						return target[1 : len(target)-1]
+					}
+				}
+			}
+		}
+	}
+
+	return ""
+}
+
+// splitLinks splits a Link header value into its links. Commas inside the
+// <...> target, which URLs may contain, and inside quoted parameter values
+// do not separate links.
+func splitLinks(value string) []string {
+	var (
+		links          []string
+		start          int
+		inURL, inQuote bool
+	)
+
+	for i, r := range value {
+		switch {
+		case inURL:
+			inURL = r != '>'
+		case inQuote:
+			inQuote = r != '"'
+		case r == '<':
+			inURL = true
+		case r == '"':
+			inQuote = true
+		case r == ',':
+			links = append(links, value[start:i])
+			start = i + 1
+		}
+	}
+
+	return append(links, value[start:])
+}
+
+// lookupPath walks a dotted path through decoded data: map keys by name and
+// list elements by index, e.g. "data.items" or "pages.0.url".
+func lookupPath(v any, path string) (any, bool) {
+	if path == "" {
+		return v, true
+	}
+
+	for _, part := range strings.Split(path, ".") {
+		switch node := v.(type) {
+		case map[string]any:
+			next, ok := node[part]
+			if !ok {
+				return nil, false
+			}
+
+			v = next
+		case []any:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, false
+			}
+
+			v = node[i]
+		default:
+			return nil, false
+		}
+	}
+
+	return v, true
+}
+
+func withoutKey(m map[string]string, key string) map[string]string {
+	if _, ok := m[key]; !ok {
+		return m
+	}
+
+	out := make(map[string]string, len(m))
+
+	for k, v := range m {
+		if k != key {
+			out[k] = v
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,276 @@
+package ssssg
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestLinkNext(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		values []string
+		want   string
+	}{
+		{[]string{`<https://api.example.com/items?page=2>; rel="next", <https://api.example.com/items?page=5>; rel="last"`}, "https://api.example.com/items?page=2"},
+		{[]string{`</items?page=1>; rel="prev"`, `</items?page=3>; rel="next"`}, "/items?page=3"},
+		{[]string{`<https://api.example.com/items?fields=id,name&page=2>; rel="next"`}, "https://api.example.com/items?fields=id,name&page=2"},
+		{[]string{`<https://example.com/a?x=1,2>; title="first, last"; rel="prev", <https://example.com/b?x=1,2>; rel=next`}, "https://example.com/b?x=1,2"},
+		{[]string{`<https://example.com/a>; rel="prev last"`}, ""},
+		{nil, ""},
+	}
+
+	for _, tt := range tests {
+		if got := linkNext(tt.values); got != tt.want {
+			t.Errorf("linkNext(%q) = %q, want %q", tt.values, got, tt.want)
+		}
+	}
+}
+
+func TestLookupPath(t *testing.T) {
+	t.Parallel()
+
+	v := map[string]any{"meta": map[string]any{"pages": []any{"a", "b"}}}
+
+	if got, ok := lookupPath(v, "meta.pages.1"); !ok || got != "b" {
+		t.Errorf("meta.pages.1 = %v, %v", got, ok)
+	}
+
+	if _, ok := lookupPath(v, "meta.missing"); ok {
+		t.Error("meta.missing found")
+	}
+}
+
+func TestNextPage_NumericCursor(t *testing.T) {
+	t.Parallel()
+
+	page := FetchEntry{URL: "https://api.example.com/items?limit=2"}
+	c := &FollowConfig{Cursor: "meta.cursor"}
+
+	tests := map[float64]string{
+		42:          "42",
+		1e21:        "1000000000000000000000",
+		12345678901: "12345678901",
+		1.5:         "1.5",
+	}
+
+	for cursor, want := range tests {
+		next, err := nextPage(page, c, "cursor", fetchResult{}, map[string]any{"meta": map[string]any{"cursor": cursor}})
+		if err != nil {
+			t.Fatalf("nextPage(%v): %v", cursor, err)
+		}
+
+		if wantURL := "https://api.example.com/items?cursor=" + want + "&limit=2"; next == nil || next.URL != wantURL {
+			t.Errorf("nextPage(%v) = %+v, want %s", cursor, next, wantURL)
+		}
+	}
+}
+
+// newPagedServer serves 5 items, 2 per page, linking pages by Link header,
+// a next URL in the body or a cursor.
+func newPagedServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(pagedHandler())
+}
+
+// pagedHandler serves 5 items over 3 pages: with a Link header at /link and
+// with next URLs and cursors in the body elsewhere.
+func pagedHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		page := 1
+		if p := r.URL.Query().Get("page"); p != "" {
+			page, _ = strconv.Atoi(p)
+		}
+
+		if c := r.URL.Query().Get("after"); c != "" {
+			page, _ = strconv.Atoi(c)
+		}
+
+		var items []string
+		for i := (page-1)*2 + 1; i <= min(page*2, 5); i++ {
+			items = append(items, fmt.Sprintf(`{"id": %d}`, i))
+		}
+
+		next, cursor := "null", "null"
+		if page < 3 {
+			next = fmt.Sprintf(`"/body?page=%d"`, page+1)
+			cursor = fmt.Sprintf(`"%d"`, page+1)
+			w.Header().Set("Link", fmt.Sprintf(`<%s%s?page=%d>; rel="next"`, "http://"+r.Host, r.URL.Path, page+1))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		list := "[" + strings.Join(items, ",") + "]"
+		if r.URL.Path == "/link" {
+			_, _ = w.Write([]byte(list))
+
+			return
+		}
+
+		_, _ = fmt.Fprintf(w, `{"data": %s, "links": {"next": %s}, "meta": {"cursor": %s}}`, list, next, cursor)
+	}
+}
+
+func TestFetcher_ResolveFollow(t *testing.T) {
+	t.Parallel()
+
+	srv := newPagedServer(t)
+	defer srv.Close()
+
+	tests := []struct {
+		name  string
+		entry FetchEntry
+		want  int
+	}{
+		{"link header", FetchEntry{URL: srv.URL + "/link", Follow: &FollowConfig{}}, 5},
+		{"next url", FetchEntry{URL: srv.URL + "/body", Follow: &FollowConfig{Next: "links.next", Items: "data"}}, 5},
+		{"cursor", FetchEntry{URL: srv.URL + "/cursor", Follow: &FollowConfig{Cursor: "meta.cursor", CursorParam: "after", Items: "data"}}, 5},
+		{"max pages", FetchEntry{URL: srv.URL + "/link", Follow: &FollowConfig{MaxPages: 2}}, 4},
+	}
+
+	for _, tt := range tests {
+		f := NewFetcher("", srv.Client())
+
+		v, err := f.Resolve(t.Context(), tt.entry)
+		if err != nil {
+			t.Fatalf("%s: Resolve failed: %v", tt.name, err)
+		}
+
+		items, ok := v.([]any)
+		if !ok || len(items) != tt.want {
+			t.Errorf("%s: items = %#v, want %d items", tt.name, v, tt.want)
+		}
+	}
+}
+
+func TestFetcher_PrefetchFollow(t *testing.T) {
+	t.Parallel()
+
+	var hits atomic.Int32
+
+	handler := pagedHandler()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		handler(w, r)
+	}))
+	defer srv.Close()
+
+	f := NewFetcher("", srv.Client())
+	entry := FetchEntry{URL: srv.URL + "/link", Follow: &FollowConfig{}}
+
+	if err := f.Prefetch(t.Context(), entry); err != nil {
+		t.Fatalf("Prefetch failed: %v", err)
+	}
+
+	if _, err := f.Resolve(t.Context(), entry); err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+
+	// Each of the 3 pages is requested once
+	if got := hits.Load(); got != 3 {
+		t.Errorf("hits = %d, want 3", got)
+	}
+}
+
+func TestFetcher_ResolveFollowOtherHost(t *testing.T) {
+	t.Parallel()
+
+	var leaked atomic.Value
+
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		leaked.Store(r.Header.Get("Authorization"))
+		_, _ = w.Write([]byte(`[{"id": 2}]`))
+	}))
+	defer other.Close()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		w.Header().Set("Link", "<"+other.URL+`/next>; rel="next"`)
+		_, _ = w.Write([]byte(`[{"id": 1}]`))
+	}))
+	defer srv.Close()
+
+	f := NewFetcher("", http.DefaultClient)
+
+	v, err := f.Resolve(t.Context(), FetchEntry{
+		URL:     srv.URL,
+		Headers: map[string]string{"Authorization": "Bearer secret"},
+		Follow:  &FollowConfig{},
+	})
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+
+	if items, ok := v.([]any); !ok || len(items) != 2 {
+		t.Errorf("items = %#v, want 2 items", v)
+	}
+
+	if got := leaked.Load(); got != "" {
+		t.Errorf("Authorization sent to another host: %q", got)
+	}
+}
+
+func TestFetcher_ResolveFollowNotList(t *testing.T) {
+	t.Parallel()
+
+	srv := newPagedServer(t)
+	defer srv.Close()
+
+	f := NewFetcher("", srv.Client())
+
+	_, err := f.Resolve(t.Context(), FetchEntry{URL: srv.URL + "/body", Follow: &FollowConfig{Next: "links.next"}})
+	if !errors.Is(err, errFollowNotList) {
+		t.Errorf("err = %v, want errFollowNotList", err)
+	}
+}
+
+func TestFollowConfig_UnmarshalYAML(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := loadTestConfig(t, `
+global:
+  fetch:
+    on:
+      url: "https://api.example.com/a"
+      follow: true
+    off:
+      url: "https://api.example.com/b"
+      follow: false
+    cursor:
+      url: "https://api.example.com/c"
+      follow:
+        cursor: "meta.cursor"
+pages:
+  - template: "index.html"
+    output: "index.html"
+`)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	fetch := cfg.Global.Fetch
+
+	if fetch["on"].Follow == nil || *fetch["on"].Follow != (FollowConfig{}) {
+		t.Errorf("follow: true = %+v, want the Link header", fetch["on"].Follow)
+	}
+
+	if fetch["off"].Follow != nil {
+		t.Errorf("follow: false = %+v, want nil", fetch["off"].Follow)
+	}
+
+	if fetch["cursor"].Follow == nil || fetch["cursor"].Follow.Cursor != "meta.cursor" {
+		t.Errorf("follow mapping = %+v, want cursor meta.cursor", fetch["cursor"].Follow)
+	}
+}
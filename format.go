@@ -0,0 +1,165 @@
+package ssssg
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/goccy/go-yaml"
+)
+
+// Fetch formats. FormatRaw keeps the fetched content as a string.
+const (
+	FormatRaw  = "raw"
+	FormatAuto = "auto"
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+	FormatCSV  = "csv"
+	FormatTOML = "toml"
+	FormatFeed = "feed" // RSS 2.0 or Atom, as a list of items
+)
+
+var errUnknownFormat = errors.New("unknown format")
+
+func isValidFormat(format string) bool {
+	switch format {
+	case "", FormatRaw, FormatAuto, FormatJSON, FormatYAML, FormatCSV, FormatTOML, FormatFeed:
+		return true
+	}
+
+	return false
+}
+
+// detectFormat picks a format from the Content-Type header, falling back to
+// the file extension of source. Generic XML is a feed when its root element
+// is <rss> or <feed>. Unknown types are treated as raw.
+func detectFormat(source, contentType string, content []byte) string {
+	if contentType != "" {
+		if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+			switch {
+			case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+				return FormatJSON
+			case mediaType == "application/yaml" || mediaType == "application/x-yaml" || mediaType == "text/yaml":
+				return FormatYAML
+			case mediaType == "text/csv":
+				return FormatCSV
+			case mediaType == "application/toml":
+				return FormatTOML
+			case mediaType == "application/rss+xml" || mediaType == "application/atom+xml":
+				return FormatFeed
+			case (mediaType == "text/xml" || mediaType == "application/xml") && isFeedXML(content):
+				return FormatFeed
+			}
+		}
+	}
+
+	if u, err := url.Parse(source); err == nil && u.Scheme != "" {
+		source = u.Path
+	}
+
+	ext := path.Ext(strings.ToLower(source))
+	if ext == ".rss" || ext == ".atom" {
+		// Only fetched feeds; the data directory keeps such files raw.
+		return FormatFeed
+	}
+
+	return formatFromExt(ext)
+}
+
+// isFeedXML reports whether the root element of an XML document is an RSS
+// or Atom feed.
+func isFeedXML(content []byte) bool {
+	root, err := xmlRoot(content)
+
+	return err == nil && (root == "rss" || root == "feed")
+}
+
+// formatFromExt maps a file extension to a data format, or FormatRaw.
+func formatFromExt(ext string) string {
+	switch ext {
+	case ".json":
+		return FormatJSON
+	case ".yaml", ".yml":
+		return FormatYAML
+	case ".csv":
+		return FormatCSV
+	case ".toml":
+		return FormatTOML
+	}
+
+	return FormatRaw
+}
+
+// decodeData converts fetched content into template data. Raw content is
+// returned as a string; JSON, YAML and TOML become maps and slices; CSV
+// becomes a slice of maps keyed by the header row; feeds become a slice of
+// item maps.
+func decodeData(format string, content []byte) (any, error) {
+	switch format {
+	case "", FormatRaw:
+		return string(content), nil
+	case FormatJSON:
+		var v any
+		if err := json.Unmarshal(content, &v); err != nil {
+			return nil, fmt.Errorf("decode json: %w", err)
+		}
+
+		return v, nil
+	case FormatYAML:
+		var v any
+		if err := yaml.Unmarshal(content, &v); err != nil {
+			return nil, fmt.Errorf("decode yaml: %w", err)
+		}
+
+		return v, nil
+	case FormatCSV:
+		return decodeCSV(content)
+	case FormatTOML:
+		v := make(map[string]any)
+		if err := toml.Unmarshal(content, &v); err != nil {
+			return nil, fmt.Errorf("decode toml: %w", err)
+		}
+
+		return v, nil
+	case FormatFeed:
+		return decodeFeed(content)
+	}
+
+	return nil, fmt.Errorf("%w: %s", errUnknownFormat, format)
+}
+
+// decodeCSV reads CSV with a header row into a slice of maps.
+func decodeCSV(content []byte) (any, error) {
+	records, err := csv.NewReader(bytes.NewReader(content)).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("decode csv: %w", err)
+	}
+
+	rows := make([]any, 0, max(len(records)-1, 0))
+
+	if len(records) == 0 {
+		return rows, nil
+	}
+
+	header := records[0]
+
+	for _, record := range records[1:] {
+		row := make(map[string]any, len(header))
+		for i, name := range header {
+			if i < len(record) {
+				row[name] = record[i]
+			}
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
@@ -0,0 +1,125 @@
+package ssssg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDecodeData(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		format  string
+		content string
+		want    any
+	}{
+		{
+			name:    "raw",
+			format:  FormatRaw,
+			content: `{"a": 1}`,
+			want:    `{"a": 1}`,
+		},
+		{
+			name:    "default is raw",
+			format:  "",
+			content: "plain",
+			want:    "plain",
+		},
+		{
+			name:    "json",
+			format:  FormatJSON,
+			content: `{"items": [{"name": "a"}, {"name": "b"}]}`,
+			want: map[string]any{"items": []any{
+				map[string]any{"name": "a"},
+				map[string]any{"name": "b"},
+			}},
+		},
+		{
+			name:    "yaml",
+			format:  FormatYAML,
+			content: "items:\n  - name: a\n",
+			want:    map[string]any{"items": []any{map[string]any{"name": "a"}}},
+		},
+		{
+			name:    "csv",
+			format:  FormatCSV,
+			content: "name,price\napple,100\nbanana,80\n",
+			want: []any{
+				map[string]any{"name": "apple", "price": "100"},
+				map[string]any{"name": "banana", "price": "80"},
+			},
+		},
+		{
+			name:    "empty csv",
+			format:  FormatCSV,
+			content: "",
+			want:    []any{},
+		},
+		{
+			name:    "toml",
+			format:  FormatTOML,
+			content: "title = \"x\"\n[owner]\nname = \"me\"\n",
+			want:    map[string]any{"title": "x", "owner": map[string]any{"name": "me"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := decodeData(tt.format, []byte(tt.content))
+			if err != nil {
+				t.Fatalf("decodeData failed: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeData_Invalid(t *testing.T) {
+	t.Parallel()
+
+	if _, err := decodeData(FormatJSON, []byte("{broken")); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+
+	if _, err := decodeData("xml", []byte("<a/>")); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		source      string
+		contentType string
+		content     string
+		want        string
+	}{
+		{"https://api.example.com/items", "application/json; charset=utf-8", "", FormatJSON},
+		{"https://api.example.com/items", "application/vnd.api+json", "", FormatJSON},
+		{"https://example.com/data", "text/csv", "", FormatCSV},
+		{"https://example.com/data.yaml?v=1", "text/plain", "", FormatYAML},
+		{"data/items.yml", "", "", FormatYAML},
+		{"data/config.TOML", "", "", FormatTOML},
+		{"data/list.csv", "", "", FormatCSV},
+		{"https://blog.example.com/feed", "application/atom+xml", "", FormatFeed},
+		{"https://blog.example.com/index.rss", "", "", FormatFeed},
+		{"static/style.css", "", "", FormatRaw},
+		{"https://example.com/", "text/html", "", FormatRaw},
+		{"https://blog.example.com/feed", "text/xml", `<?xml version="1.0" encoding="ISO-8859-1"?><rss version="2.0"></rss>`, FormatFeed},
+		{"https://blog.example.com/feed", "application/xml; charset=utf-8", `<!-- atom --><feed xmlns="http://www.w3.org/2005/Atom"></feed>`, FormatFeed},
+		{"https://example.com/sitemap.xml", "application/xml", `<urlset></urlset>`, FormatRaw},
+	}
+
+	for _, tt := range tests {
+		if got := detectFormat(tt.source, tt.contentType, []byte(tt.content)); got != tt.want {
+			t.Errorf("detectFormat(%q, %q) = %q, want %q", tt.source, tt.contentType, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package ssssg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// globPrefix marks a fetch source as a file pattern, as in "glob:data/*.yaml".
+const globPrefix = "glob:"
+
+// Shapes of the result of a glob or directory source.
+const (
+	FetchAsMap  = "map"  // file contents keyed by path relative to the pattern's directory
+	FetchAsList = "list" // name, path and content of each file, sorted by path
+)
+
+var (
+	errFetchAsInvalid  = errors.New("as must be map or list")
+	errGlobPattern     = errors.New("invalid glob pattern")
+	errFetchAsNotFiles = errors.New("as requires a glob or directory source")
+)
+
+// isGlob reports whether source is a glob pattern.
+func isGlob(source string) bool {
+	return strings.HasPrefix(source, globPrefix)
+}
+
+// globBase returns the leading directories of pattern that contain no
+// pattern characters. Keys of the matched files are relative to it.
+func globBase(pattern string) string {
+	parts := strings.Split(filepath.ToSlash(pattern), "/")
+
+	for i, part := range parts[:len(parts)-1] {
+		if strings.ContainsAny(part, `*?[\`) {
+			return filepath.FromSlash(strings.Join(parts[:i], "/"))
+		}
+	}
+
+	return filepath.Dir(pattern)
+}
+
+// globFiles returns the absolute paths of the files a glob or directory
+// source matches, sorted, and the directory their keys are relative to. ok is
+// false for sources that name a single file or URL. Directories are not
+// descended into, and dotfiles are skipped like in the static directory.
+func (f *Fetcher) globFiles(source string) (files []string, base string, ok bool, err error) {
+	if source == "" || isRemote(source) {
+		return nil, "", false, nil
+	}
+
+	var pattern string
+
+	if isGlob(source) {
+		pattern = f.absPath(strings.TrimPrefix(source, globPrefix))
+		base = globBase(pattern)
+	} else {
+		info, statErr := os.Stat(f.absPath(source))
+		if statErr != nil || !info.IsDir() {
+			return nil, "", false, nil
+		}
+
+		base = f.absPath(source)
+		pattern = filepath.Join(base, "*")
+	}
+
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, "", true, fmt.Errorf("%w: %s", errGlobPattern, source)
+	}
+
+	for _, match := range matches {
+		if strings.HasPrefix(filepath.Base(match), ".") {
+			continue
+		}
+
+		if info, err := os.Stat(match); err != nil || info.IsDir() {
+			continue
+		}
+
+		files = append(files, match)
+	}
+
+	sort.Strings(files)
+
+	return files, base, true, nil
+}
+
+// resolveFiles reads and decodes every file of a glob or directory source.
+// Without a format, or with auto, each file is decoded according to its
+// extension.
+func (f *Fetcher) resolveFiles(ctx context.Context, entry FetchEntry, files []string, base string) (any, error) {
+	list := make([]any, 0, len(files))
+	byKey := make(map[string]any, len(files))
+
+	for _, file := range files {
+		fileEntry := FetchEntry{URL: file, Encoding: entry.Encoding, MaxBodySize: entry.MaxBodySize}
+
+		res, err := f.fetch(ctx, fileEntry)
+		if err != nil {
+			return nil, err
+		}
+
+		format := entry.Format
+		if format == "" || format == FormatAuto {
+			format = detectFormat(file, "", nil)
+		}
+
+		var v any
+
+		if entry.Select != "" {
+			v, err = selectHTML(res.body, entry.Select, entry.Extract)
+		} else {
+			v, err = decodeData(format, []byte(res.body))
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("fetch %s: %w", fileEntry.describe(), err)
+		}
+
+		key, err := filepath.Rel(base, file)
+		if err != nil {
+			return nil, fmt.Errorf("relative path: %w", err)
+		}
+
+		byKey[filepath.ToSlash(key)] = v
+		list = append(list, map[string]any{
+			"name":    filepath.Base(file),
+			"path":    f.relPath(file),
+			"content": v,
+		})
+	}
+
+	if entry.As == FetchAsList {
+		return list, nil
+	}
+
+	return byKey, nil
+}
+
+func (f *Fetcher) absPath(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+
+	return filepath.Join(f.baseDir, path)
+}
+
+// relPath returns path relative to the base directory with forward slashes,
+// or path itself when it is outside of it.
+func (f *Fetcher) relPath(path string) string {
+	rel, err := filepath.Rel(f.baseDir, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(path)
+	}
+
+	return filepath.ToSlash(rel)
+}
+
+func validateGlob(entry FetchEntry) error {
+	if isGlob(entry.URL) {
+		if _, err := filepath.Match(strings.TrimPrefix(entry.URL, globPrefix), ""); err != nil {
+			return fmt.Errorf("%w: %s", errGlobPattern, entry.URL)
+		}
+	}
+
+	switch entry.As {
+	case "", FetchAsMap, FetchAsList:
+	default:
+		return fmt.Errorf("%w: %s", errFetchAsInvalid, entry.As)
+	}
+
+	if entry.As != "" && (isRemote(entry.URL) || entry.Exec != nil) {
+		return errFetchAsNotFiles
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package ssssg
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFetcher_ResolveGlob(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"data/authors.yaml":        "- alice\n- bob\n",
+		"data/site.json":           `{"name": "ssssg"}`,
+		"data/.hidden.yaml":        "secret: true\n",
+		"data/nested/skip.yaml":    "skip: true\n",
+		"snippets/header.html":     "<header></header>",
+		"snippets/footer.html":     "<footer></footer>",
+		"content/a/index.md":       "a",
+		"content/b/index.md":       "b",
+		"content/b/other/index.md": "other",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		entry FetchEntry
+		want  any
+	}{
+		{
+			name:  "glob decodes by extension",
+			entry: FetchEntry{URL: "glob:data/*.*"},
+			want: map[string]any{
+				"authors.yaml": []any{"alice", "bob"},
+				"site.json":    map[string]any{"name": "ssssg"},
+			},
+		},
+		{
+			name:  "directory",
+			entry: FetchEntry{URL: "snippets"},
+			want: map[string]any{
+				"footer.html": "<footer></footer>",
+				"header.html": "<header></header>",
+			},
+		},
+		{
+			name:  "keys relative to the pattern's directory",
+			entry: FetchEntry{URL: "glob:content/*/index.md"},
+			want: map[string]any{
+				"a/index.md": "a",
+				"b/index.md": "b",
+			},
+		},
+		{
+			name:  "list",
+			entry: FetchEntry{URL: "glob:snippets/*.html", As: FetchAsList},
+			want: []any{
+				map[string]any{"name": "footer.html", "path": "snippets/footer.html", "content": "<footer></footer>"},
+				map[string]any{"name": "header.html", "path": "snippets/header.html", "content": "<header></header>"},
+			},
+		},
+		{
+			name:  "raw format",
+			entry: FetchEntry{URL: "glob:data/*.json", Format: FormatRaw},
+			want:  map[string]any{"site.json": `{"name": "ssssg"}`},
+		},
+		{
+			name:  "no matches",
+			entry: FetchEntry{URL: "glob:data/*.csv", As: FetchAsList},
+			want:  []any{},
+		},
+	}
+
+	f := NewFetcher(dir, nil)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if err := f.Prefetch(context.Background(), tt.entry); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := f.Resolve(context.Background(), tt.entry)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateGlob(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		entry FetchEntry
+		want  error
+	}{
+		{FetchEntry{URL: "glob:data/*.yaml", As: FetchAsList}, nil},
+		{FetchEntry{URL: "data", As: FetchAsMap}, nil},
+		{FetchEntry{URL: "glob:data/[*.yaml"}, errGlobPattern},
+		{FetchEntry{URL: "glob:data/*.yaml", As: "tree"}, errFetchAsInvalid},
+		{FetchEntry{URL: "https://example.com/items", As: FetchAsList}, errFetchAsNotFiles},
+	}
+
+	for _, tt := range tests {
+		if err := validateGlob(tt.entry); !errors.Is(err, tt.want) {
+			t.Errorf("validateGlob(%+v) = %v, want %v", tt.entry, err, tt.want)
+		}
+	}
+}
@@ -5,10 +5,16 @@ go 1.25
 tool github.com/golangci/golangci-lint/cmd/golangci-lint
 
 require (
+	github.com/BurntSushi/toml v1.5.0
+	github.com/PuerkitoBio/goquery v1.11.0
+	github.com/andybalholm/cascadia v1.3.3
 	github.com/goccy/go-yaml v1.19.2
 	github.com/spf13/cobra v1.9.1
+	github.com/yuin/goldmark v1.8.2
 	golang.org/x/image v0.35.0
+	golang.org/x/net v0.48.0
 	golang.org/x/sync v0.19.0
+	golang.org/x/text v0.33.0
 )
 
 require (
@@ -19,7 +25,6 @@ require (
 	github.com/Antonboom/errname v1.0.0 // indirect
 	github.com/Antonboom/nilnil v1.0.1 // indirect
 	github.com/Antonboom/testifylint v1.5.2 // indirect
-	github.com/BurntSushi/toml v1.4.1-0.20240526193622-a339e1f7089c // indirect
 	github.com/Crocmagnon/fatcontext v0.7.1 // indirect
 	github.com/Djarvur/go-err113 v0.0.0-20210108212216-aea10b59be24 // indirect
 	github.com/GaijinEntertainment/go-exhaustruct/v3 v3.3.1 // indirect
@@ -186,7 +191,6 @@ require (
 	golang.org/x/exp/typeparams v0.0.0-20250210185358-939b2ce775ac // indirect
 	golang.org/x/mod v0.31.0 // indirect
 	golang.org/x/sys v0.39.0 // indirect
-	golang.org/x/text v0.33.0 // indirect
 	golang.org/x/tools v0.40.0 // indirect
 	golang.org/x/tools/go/expect v0.1.1-deprecated // indirect
 	golang.org/x/tools/go/packages/packagestest v0.1.1-deprecated // indirect
@@ -0,0 +1,126 @@
+package ssssg
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FetchTypeGraphQL marks a fetch entry as a GraphQL query.
+const FetchTypeGraphQL = "graphql"
+
+var (
+	errGraphQL          = errors.New("graphql error")
+	errGraphQLNoData    = errors.New("graphql response has no data")
+	errGraphQLQuery     = errors.New("graphql requires exactly one of graphql or graphql_file")
+	errGraphQLOptions   = errors.New("graphql entries must not set format, method, body, query, select or encoding")
+	errFetchTypeUnknown = errors.New("unknown fetch type")
+)
+
+type graphQLRequest struct {
+	Query     string         `json:"query"`
+	Variables map[string]any `json:"variables,omitempty"`
+}
+
+type graphQLResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// graphQLEntry turns a GraphQL entry into the POST request that runs it.
+// The query is read from GraphQLFile, relative to the base directory, when
+// it is not inline. The JSON body is sent as is, so "$" in the query is not
+// taken for an environment variable.
+func (f *Fetcher) graphQLEntry(entry FetchEntry) (FetchEntry, error) {
+	query := entry.GraphQL
+
+	if entry.GraphQLFile != "" {
+		path := entry.GraphQLFile
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(f.baseDir, path)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return FetchEntry{}, fmt.Errorf("read graphql file: %w", err)
+		}
+
+		query = string(data)
+	}
+
+	body, err := json.Marshal(graphQLRequest{Query: query, Variables: entry.Variables})
+	if err != nil {
+		return FetchEntry{}, fmt.Errorf("encode graphql request: %w", err)
+	}
+
+	// Canonical keys, so that a user's content-type is replaced rather than
+	// sent next to ours
+	headers := make(map[string]string, len(entry.Headers)+2)
+	for k, v := range entry.Headers {
+		headers[http.CanonicalHeaderKey(k)] = v
+	}
+
+	headers["Content-Type"] = "application/json"
+	if _, ok := headers["Accept"]; !ok {
+		headers["Accept"] = "application/json"
+	}
+
+	return FetchEntry{
+		URL:         entry.URL,
+		TTL:         entry.TTL,
+		Method:      http.MethodPost,
+		Headers:     headers,
+		Body:        string(body),
+		MaxBodySize: entry.MaxBodySize,
+		literalBody: true,
+	}, nil
+}
+
+// decodeGraphQL returns the data object of a GraphQL response. Errors in
+// the response fail the fetch even when partial data is present.
+func decodeGraphQL(body []byte) (any, error) {
+	var resp graphQLResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("decode graphql response: %w", err)
+	}
+
+	if len(resp.Errors) > 0 {
+		messages := make([]string, 0, len(resp.Errors))
+		for _, e := range resp.Errors {
+			messages = append(messages, e.Message)
+		}
+
+		return nil, fmt.Errorf("%w: %s", errGraphQL, strings.Join(messages, "; "))
+	}
+
+	if len(resp.Data) == 0 || string(resp.Data) == "null" {
+		return nil, errGraphQLNoData
+	}
+
+	return decodeData(FormatJSON, resp.Data)
+}
+
+func validateGraphQL(entry FetchEntry) error {
+	if (entry.GraphQL == "") == (entry.GraphQLFile == "") {
+		return errGraphQLQuery
+	}
+
+	// The response is always JSON, so the HTML and charset options of raw
+	// fetches do not apply.
+	if entry.Format != "" || entry.Method != "" || entry.Body != "" || len(entry.Query) > 0 ||
+		entry.Select != "" || entry.Extract != "" || entry.Encoding != "" {
+		return errGraphQLOptions
+	}
+
+	if !isRemote(entry.URL) {
+		return errFetchOptionsLocal
+	}
+
+	return nil
+}
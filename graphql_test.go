@@ -0,0 +1,158 @@
+package ssssg
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newGraphQLServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req graphQLRequest
+		if r.Method != http.MethodPost || json.NewDecoder(r.Body).Decode(&req) != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		if req.Query == "{ broken }" {
+			_, _ = w.Write([]byte(`{"data": null, "errors": [{"message": "Cannot query field \"broken\""}]}`))
+
+			return
+		}
+
+		resp := map[string]any{
+			"data": map[string]any{
+				"posts": []any{map[string]any{"title": "Hello", "first": req.Variables["first"]}},
+				"auth":  r.Header.Get("Authorization"),
+			},
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+}
+
+func TestFetcher_ResolveGraphQL(t *testing.T) {
+	t.Parallel()
+
+	srv := newGraphQLServer(t)
+	defer srv.Close()
+
+	dir := t.TempDir()
+
+	query := "query Posts($first: Int) { posts(first: $first) { title } }"
+	if err := os.WriteFile(filepath.Join(dir, "posts.graphql"), []byte(query), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	f := NewFetcher(dir, srv.Client())
+
+	entries := []FetchEntry{
+		{URL: srv.URL, Type: FetchTypeGraphQL, GraphQL: query, Variables: map[string]any{"first": 2}},
+		{URL: srv.URL, Type: FetchTypeGraphQL, GraphQLFile: "posts.graphql", Variables: map[string]any{"first": 3},
+			Headers: map[string]string{"Authorization": "Bearer x"}},
+	}
+
+	for i, entry := range entries {
+		v, err := f.Resolve(t.Context(), entry)
+		if err != nil {
+			t.Fatalf("entries[%d]: Resolve failed: %v", i, err)
+		}
+
+		data, ok := v.(map[string]any)
+		if !ok {
+			t.Fatalf("entries[%d]: data = %#v", i, v)
+		}
+
+		posts, _ := data["posts"].([]any)
+		if len(posts) != 1 {
+			t.Fatalf("entries[%d]: posts = %#v", i, data["posts"])
+		}
+
+		post, _ := posts[0].(map[string]any)
+		if post["title"] != "Hello" || post["first"] != float64(2+i) {
+			t.Errorf("entries[%d]: post = %#v", i, post)
+		}
+	}
+}
+
+func TestFetcher_ResolveGraphQLErrors(t *testing.T) {
+	t.Parallel()
+
+	srv := newGraphQLServer(t)
+	defer srv.Close()
+
+	f := NewFetcher("", srv.Client())
+
+	_, err := f.Resolve(t.Context(), FetchEntry{URL: srv.URL, Type: FetchTypeGraphQL, GraphQL: "{ broken }"})
+	if !errors.Is(err, errGraphQL) {
+		t.Fatalf("err = %v, want errGraphQL", err)
+	}
+
+	if want := `Cannot query field "broken"`; !strings.Contains(err.Error(), want) {
+		t.Errorf("err = %v, want it to contain %q", err, want)
+	}
+}
+
+func TestFetcher_GraphQLEntryHeaders(t *testing.T) {
+	t.Parallel()
+
+	f := NewFetcher(t.TempDir(), nil)
+
+	entry, err := f.graphQLEntry(FetchEntry{
+		URL:     "https://example.com/graphql",
+		Type:    FetchTypeGraphQL,
+		GraphQL: "{ a }",
+		Headers: map[string]string{"content-type": "text/plain", "accept": "application/graphql-response+json"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := entry.newRequest(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := req.Header.Values("Content-Type"); len(got) != 1 || got[0] != "application/json" {
+		t.Errorf("Content-Type = %q, want only application/json", got)
+	}
+
+	if got := req.Header.Values("Accept"); len(got) != 1 || got[0] != "application/graphql-response+json" {
+		t.Errorf("Accept = %q, want the user's value", got)
+	}
+}
+
+func TestValidateGraphQL(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		entry FetchEntry
+		want  error
+	}{
+		{"no query", FetchEntry{URL: "https://example.com/graphql", Type: FetchTypeGraphQL}, errGraphQLQuery},
+		{"both queries", FetchEntry{URL: "https://example.com/graphql", Type: FetchTypeGraphQL, GraphQL: "{ a }", GraphQLFile: "a.graphql"}, errGraphQLQuery},
+		{"format", FetchEntry{URL: "https://example.com/graphql", Type: FetchTypeGraphQL, GraphQL: "{ a }", Format: FormatJSON}, errGraphQLOptions},
+		{"encoding", FetchEntry{URL: "https://example.com/graphql", Type: FetchTypeGraphQL, GraphQL: "{ a }", Encoding: "shift_jis"}, errGraphQLOptions},
+		{"select", FetchEntry{URL: "https://example.com/graphql", Type: FetchTypeGraphQL, GraphQL: "{ a }", Select: "h1"}, errSelectOptions},
+		{"local", FetchEntry{URL: "graphql.json", Type: FetchTypeGraphQL, GraphQL: "{ a }"}, errFetchOptionsLocal},
+		{"unknown type", FetchEntry{URL: "https://example.com/", Type: "soap"}, errFetchTypeUnknown},
+		{"follow", FetchEntry{URL: "https://example.com/graphql", Type: FetchTypeGraphQL, GraphQL: "{ a }", Follow: &FollowConfig{}}, errFollowOptions},
+		{"valid", FetchEntry{URL: "https://example.com/graphql", Type: FetchTypeGraphQL, GraphQL: "{ a }"}, nil},
+	}
+
+	for _, tt := range tests {
+		if err := validateFetchEntry(tt.entry); !errors.Is(err, tt.want) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,464 @@
+package ssssg
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"text/template/parse"
+)
+
+// manifestVersion is bumped whenever the fingerprint inputs change so that
+// manifests written by older versions are ignored.
+const manifestVersion = 3
+
+const manifestFile = "manifest.json"
+
+// buildManifest records what every output was built from in the previous
+// build. Entries are fingerprints (hashes) of the inputs, so comparing them is
+// enough to decide whether the work has to be redone.
+type buildManifest struct {
+	Version int                   `json:"version"`
+	Static  map[string]string     `json:"static"` // static rel path -> source + pipeline fingerprint
+	Scan    map[string]scanRecord `json:"scan"`   // output rel path -> cached metadata
+	Pages   map[string]string     `json:"pages"`  // page output -> input fingerprint
+	// Generated lists files written after rendering, such as the sitemap.
+	Generated []string `json:"generated"`
+}
+
+type scanRecord struct {
+	ModTime int64          `json:"mod_time"` // unix nanoseconds
+	Size    int64          `json:"size"`
+	Info    StaticFileInfo `json:"info"`
+}
+
+func newBuildManifest() buildManifest {
+	return buildManifest{
+		Version: manifestVersion,
+		Static:  make(map[string]string),
+		Scan:    make(map[string]scanRecord),
+		Pages:   make(map[string]string),
+	}
+}
+
+// buildCache compares the inputs of the current build with the previous
+// manifest and collects the manifest for the next build. A nil *buildCache
+// disables incremental behavior: every check reports "changed".
+type buildCache struct {
+	dir  string
+	prev buildManifest
+
+	mu   sync.Mutex
+	next buildManifest
+}
+
+// loadBuildCache reads the manifest from dir. A missing or unreadable
+// manifest, or force, starts from an empty one so everything is rebuilt.
+func loadBuildCache(dir string, force bool) *buildCache {
+	c := &buildCache{
+		dir:  dir,
+		prev: newBuildManifest(),
+		next: newBuildManifest(),
+	}
+
+	if force {
+		return c
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, manifestFile))
+	if err != nil {
+		return c
+	}
+
+	prev := newBuildManifest()
+	if err := json.Unmarshal(data, &prev); err != nil || prev.Version != manifestVersion {
+		return c
+	}
+
+	c.prev = prev
+
+	return c
+}
+
+// save writes the manifest collected during this build.
+func (c *buildCache) save() error {
+	if c == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	data, err := json.Marshal(c.next)
+	c.mu.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("encode manifest: %w", err)
+	}
+
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return fmt.Errorf("create cache dir: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(c.dir, manifestFile), data, 0o644); err != nil { //nolint:gosec
+		return fmt.Errorf("write manifest: %w", err)
+	}
+
+	return nil
+}
+
+// staticFresh records the fingerprint of a static source file and reports
+// whether it matches the previous build and the destination still exists.
+func (c *buildCache) staticFresh(relPath, fingerprint, destPath string) bool {
+	if c == nil {
+		return false
+	}
+
+	c.mu.Lock()
+	c.next.Static[relPath] = fingerprint
+	prev, ok := c.prev.Static[relPath]
+	c.mu.Unlock()
+
+	return ok && prev == fingerprint && fileExists(destPath)
+}
+
+// cachedScan returns the metadata scanned in the previous build if the file
+// has the same size and modification time.
+func (c *buildCache) cachedScan(relPath string, info fs.FileInfo) (StaticFileInfo, bool) {
+	if c == nil {
+		return StaticFileInfo{}, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	rec, ok := c.prev.Scan[relPath]
+	if !ok || rec.Size != info.Size() || rec.ModTime != info.ModTime().UnixNano() {
+		return StaticFileInfo{}, false
+	}
+
+	return rec.Info, true
+}
+
+func (c *buildCache) recordScan(relPath string, info fs.FileInfo, si StaticFileInfo) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	c.next.Scan[relPath] = scanRecord{
+		ModTime: info.ModTime().UnixNano(),
+		Size:    info.Size(),
+		Info:    si,
+	}
+	c.mu.Unlock()
+}
+
+// pageFresh records the input fingerprint of a page output and reports
+// whether it matches the previous build and the output file still exists.
+// An empty fingerprint means the inputs could not be hashed and is never fresh.
+func (c *buildCache) pageFresh(output, fingerprint, outputPath string) bool {
+	if c == nil || fingerprint == "" {
+		return false
+	}
+
+	c.mu.Lock()
+	c.next.Pages[output] = fingerprint
+	prev, ok := c.prev.Pages[output]
+	c.mu.Unlock()
+
+	return ok && prev == fingerprint && fileExists(outputPath)
+}
+
+// recordGenerated records a file written after rendering so that the next
+// build does not treat it as a static input.
+func (c *buildCache) recordGenerated(output string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	c.next.Generated = append(c.next.Generated, output)
+	c.mu.Unlock()
+}
+
+// staticFingerprint identifies a static source file by size, modification
+// time and the commands of the pipeline that processes it.
+func staticFingerprint(info fs.FileInfo, pipeline *PipelineConfig) string {
+	parts := []string{
+		strconv.FormatInt(info.Size(), 10),
+		strconv.FormatInt(info.ModTime().UnixNano(), 10),
+	}
+
+	if pipeline != nil {
+		parts = append(parts, pipeline.Commands...)
+	}
+
+	return hashStrings(parts...)
+}
+
+// templateHashes fingerprints template files: every shared file (_*.html)
+// and each page template. The returned map is keyed by page template name.
+func templateHashes(templateDir string, pages []PageConfig) (map[string]string, error) {
+	sharedFiles, err := filepath.Glob(filepath.Join(templateDir, "_*.html"))
+	if err != nil {
+		return nil, fmt.Errorf("glob shared templates: %w", err)
+	}
+
+	sort.Strings(sharedFiles)
+
+	shared := make([]string, 0, len(sharedFiles)*2)
+
+	for _, file := range sharedFiles {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("read shared template %s: %w", file, err)
+		}
+
+		shared = append(shared, filepath.Base(file), string(content))
+	}
+
+	sharedHash := hashStrings(shared...)
+
+	hashes := make(map[string]string)
+
+	for _, page := range pages {
+		if _, ok := hashes[page.Template]; ok {
+			continue
+		}
+
+		// A missing page template is reported by the renderer; hash what we have.
+		content, _ := os.ReadFile(filepath.Join(templateDir, page.Template))
+		hashes[page.Template] = hashStrings(sharedHash, page.Template, string(content))
+	}
+
+	return hashes, nil
+}
+
+// staticInputs returns the static metadata without the rendered page
+// outputs. Those are scanned from the output directory too, and letting them
+// into the fingerprint would invalidate every page whenever one page changes.
+// Outputs recorded in the previous build cover pages that emit several
+// outputs, such as paginated lists, and generated files like the sitemap.
+func staticInputs(staticMeta map[string]StaticFileInfo, pages []PageConfig, cache *buildCache) map[string]StaticFileInfo {
+	outputs := make(map[string]struct{}, len(pages))
+	for _, page := range pages {
+		outputs[filepath.ToSlash(filepath.Clean(page.Output))] = struct{}{}
+	}
+
+	if cache != nil {
+		for output := range cache.prev.Pages {
+			outputs[filepath.ToSlash(filepath.Clean(output))] = struct{}{}
+		}
+
+		for _, output := range cache.prev.Generated {
+			outputs[filepath.ToSlash(filepath.Clean(output))] = struct{}{}
+		}
+	}
+
+	inputs := make(map[string]StaticFileInfo, len(staticMeta))
+
+	for relPath, si := range staticMeta {
+		if _, ok := outputs[relPath]; !ok {
+			inputs[relPath] = si
+		}
+	}
+
+	return inputs
+}
+
+// sharedFields are the fields of TemplateData that are the same for every
+// page. A page is only re-rendered for a change in the ones its templates use.
+//
+//nolint:gochecknoglobals
+var sharedFields = []string{"Global", "Data", "Static", "Pages", "Feeds"}
+
+// sharedFieldsUsed reports which shared fields the templates of tmpl may
+// read. It looks for .Field and $.Field references anywhere in the set, so a
+// field read inside range or with, where dot is something else, still counts;
+// over-reporting only costs a render. Using the root data itself, as $, as
+// dot outside range and with, or through a variable assigned from either,
+// could read anything and counts as using every field. Every template of the
+// set may be executed with the root data, so each starts with dot as root.
+func sharedFieldsUsed(tmpl *template.Template) map[string]bool {
+	w := fieldWalker{
+		used:     make(map[string]bool),
+		rootVars: map[string]bool{"$": true},
+	}
+
+	for _, t := range tmpl.Templates() {
+		if t.Tree != nil {
+			w.walk(t.Tree.Root, true)
+		}
+	}
+
+	return w.used
+}
+
+// fieldWalker collects the shared fields used by template parse trees.
+type fieldWalker struct {
+	used     map[string]bool
+	rootVars map[string]bool // variables holding the root data
+}
+
+// walk visits node; root reports whether dot is the root data there.
+func (w *fieldWalker) walk(node parse.Node, root bool) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+
+		for _, child := range n.Nodes {
+			w.walk(child, root)
+		}
+	case *parse.ActionNode:
+		w.walk(n.Pipe, root)
+	case *parse.IfNode:
+		// The truth of the root data reads none of its fields
+		if !w.rootPipe(n.Pipe, root) {
+			w.walk(n.Pipe, root)
+		}
+
+		w.walk(n.List, root)
+		w.walk(n.ElseList, root)
+	case *parse.RangeNode:
+		w.walk(n.Pipe, root)
+		w.walk(n.List, false)
+		w.walk(n.ElseList, root)
+	case *parse.WithNode:
+		inner := w.rootPipe(n.Pipe, root)
+		if !inner || len(n.Pipe.Decl) > 0 {
+			w.walk(n.Pipe, root)
+		}
+
+		w.walk(n.List, inner)
+		w.walk(n.ElseList, root)
+	case *parse.TemplateNode:
+		// The invoked template is walked on its own; passing the root is fine.
+		if n.Pipe != nil {
+			for _, cmd := range n.Pipe.Cmds {
+				for _, arg := range cmd.Args {
+					if !w.isRoot(arg, root) {
+						w.walk(arg, root)
+					}
+				}
+			}
+		}
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+
+		// {{ $r := . }} only binds the root; what $r is used for counts
+		if len(n.Decl) > 0 && w.rootPipe(n, root) {
+			for _, v := range n.Decl {
+				w.rootVars[v.Ident[0]] = true
+			}
+
+			return
+		}
+
+		for _, cmd := range n.Cmds {
+			w.walk(cmd, root)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			w.walk(arg, root)
+		}
+	case *parse.ChainNode:
+		w.walk(n.Node, root)
+	case *parse.FieldNode:
+		w.used[n.Ident[0]] = true
+	case *parse.VariableNode:
+		if len(n.Ident) > 1 {
+			w.used[n.Ident[1]] = true
+		} else if w.rootVars[n.Ident[0]] {
+			w.useAll()
+		}
+	case *parse.DotNode:
+		if root {
+			w.useAll()
+		}
+	}
+}
+
+// isRoot reports whether arg is the root data itself.
+func (w *fieldWalker) isRoot(arg parse.Node, root bool) bool {
+	switch a := arg.(type) {
+	case *parse.DotNode:
+		return root
+	case *parse.VariableNode:
+		return len(a.Ident) == 1 && w.rootVars[a.Ident[0]]
+	default:
+		return false
+	}
+}
+
+// rootPipe reports whether pipe evaluates to the root data itself.
+func (w *fieldWalker) rootPipe(pipe *parse.PipeNode, root bool) bool {
+	return pipe != nil && len(pipe.Cmds) == 1 && len(pipe.Cmds[0].Args) == 1 && w.isRoot(pipe.Cmds[0].Args[0], root)
+}
+
+func (w *fieldWalker) useAll() {
+	for _, field := range sharedFields {
+		w.used[field] = true
+	}
+}
+
+// sharedFingerprint combines the hashes of the shared fields in used. It
+// returns an empty string if one of them could not be hashed.
+func sharedFingerprint(hashes map[string]string, used map[string]bool) string {
+	parts := make([]string, 0, len(sharedFields))
+
+	for _, field := range sharedFields {
+		if !used[field] {
+			parts = append(parts, "")
+
+			continue
+		}
+
+		if hashes[field] == "" {
+			return ""
+		}
+
+		parts = append(parts, field+":"+hashes[field])
+	}
+
+	return hashStrings(parts...)
+}
+
+// hashJSON fingerprints a value through its JSON encoding. Map keys are
+// sorted by encoding/json, so equal data gives equal hashes. It returns an
+// empty string when the value cannot be encoded.
+func hashJSON(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+
+	return hashStrings(string(data))
+}
+
+func hashStrings(parts ...string) string {
+	h := sha256.New()
+
+	for _, p := range parts {
+		_, _ = h.Write([]byte(strconv.Itoa(len(p))))
+		_, _ = h.Write([]byte{0})
+		_, _ = h.Write([]byte(p))
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+
+	return err == nil
+}
@@ -0,0 +1,425 @@
+package ssssg
+
+import (
+	"bytes"
+	"html/template"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func buildWithLog(t *testing.T, dir string, force bool) string {
+	t.Helper()
+
+	var log bytes.Buffer
+
+	err := Build(t.Context(), BuildOptions{
+		ConfigPath: filepath.Join(dir, "site.yaml"),
+		Timeout:    10 * time.Second,
+		Log:        &log,
+		Force:      force,
+	})
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	return log.String()
+}
+
+func TestBuild_IncrementalPages(t *testing.T) {
+	t.Parallel()
+
+	yaml := `
+global:
+  layout: "_layout.html"
+  data:
+    site_name: "Test"
+
+pages:
+  - template: "index.html"
+    output: "index.html"
+    data:
+      title: "Home"
+  - template: "about.html"
+    output: "about/index.html"
+    data:
+      title: "About"
+`
+
+	dir := setupProject(t, yaml)
+	tmplDir := filepath.Join(dir, "templates")
+
+	files := map[string]string{
+		"_layout.html": `<html><body>{{ block "content" . }}{{ end }}</body></html>`,
+		"index.html":   `{{ define "content" }}<h1>{{ .Page.title }}</h1>{{ end }}`,
+		"about.html":   `{{ define "content" }}<h2>{{ .Page.title }}</h2>{{ end }}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmplDir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	log := buildWithLog(t, dir, false)
+	if strings.Contains(log, "Unchanged:") {
+		t.Fatalf("first build should render everything:\n%s", log)
+	}
+
+	// Second build: nothing changed
+	log = buildWithLog(t, dir, false)
+	if !strings.Contains(log, "Unchanged: index.html") || !strings.Contains(log, "Unchanged: about/index.html") {
+		t.Errorf("second build should skip all pages:\n%s", log)
+	}
+
+	// Changing a page template only re-renders that page
+	if err := os.WriteFile(filepath.Join(tmplDir, "about.html"), []byte(`{{ define "content" }}<h3>{{ .Page.title }}</h3>{{ end }}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	log = buildWithLog(t, dir, false)
+	if !strings.Contains(log, "Unchanged: index.html") || !strings.Contains(log, "Generated: about/index.html") {
+		t.Errorf("only about should be re-rendered:\n%s", log)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, "public", "about", "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(content), "<h3>About</h3>") {
+		t.Errorf("about not re-rendered:\n%s", content)
+	}
+
+	// Changing a shared template re-renders every page
+	if err := os.WriteFile(filepath.Join(tmplDir, "_layout.html"), []byte(`<html><main>{{ block "content" . }}{{ end }}</main></html>`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	log = buildWithLog(t, dir, false)
+	if strings.Contains(log, "Unchanged:") {
+		t.Errorf("layout change should re-render every page:\n%s", log)
+	}
+
+	// A deleted output is rebuilt
+	if err := os.Remove(filepath.Join(dir, "public", "index.html")); err != nil {
+		t.Fatal(err)
+	}
+
+	log = buildWithLog(t, dir, false)
+	if !strings.Contains(log, "Generated: index.html") {
+		t.Errorf("missing output should be rebuilt:\n%s", log)
+	}
+
+	// Force ignores the cache
+	log = buildWithLog(t, dir, true)
+	if strings.Contains(log, "Unchanged:") {
+		t.Errorf("force should re-render every page:\n%s", log)
+	}
+}
+
+func TestBuild_IncrementalConfigData(t *testing.T) {
+	t.Parallel()
+
+	dir := setupProject(t, `
+global:
+  data:
+    site_name: "Before"
+pages:
+  - template: "index.html"
+    output: "index.html"
+`)
+
+	if err := os.WriteFile(filepath.Join(dir, "templates", "index.html"), []byte(`{{ .Global.site_name }}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	buildWithLog(t, dir, false)
+
+	if err := os.WriteFile(filepath.Join(dir, "site.yaml"), []byte(`
+global:
+  data:
+    site_name: "After"
+pages:
+  - template: "index.html"
+    output: "index.html"
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	log := buildWithLog(t, dir, false)
+	if !strings.Contains(log, "Generated: index.html") {
+		t.Errorf("global data change should re-render:\n%s", log)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, "public", "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(content) != "After" {
+		t.Errorf("content = %q, want %q", content, "After")
+	}
+}
+
+func TestBuild_IncrementalSharedFields(t *testing.T) {
+	t.Parallel()
+
+	dir := setupProject(t, `
+global:
+  data:
+    site_name: "Test"
+pages:
+  - template: "index.html"
+    output: "index.html"
+  - template: "stats.html"
+    output: "stats.html"
+  - template: "list.html"
+    output: "list.html"
+`)
+
+	files := map[string]string{
+		"templates/index.html": `{{ .Global.site_name }}`,
+		"templates/stats.html": `{{ range $k, $v := .Data.stats }}{{ $k }}={{ $v }}{{ end }}`,
+		"templates/list.html":  `{{ range .Pages }}{{ .URL }} {{ end }}`,
+		"data/stats.json":      `{"visits": 1}`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	buildWithLog(t, dir, false)
+
+	// A data file change only re-renders the page reading .Data
+	if err := os.WriteFile(filepath.Join(dir, "data", "stats.json"), []byte(`{"visits": 2}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	log := buildWithLog(t, dir, false)
+	for _, want := range []string{"Unchanged: index.html", "Generated: stats.html", "Unchanged: list.html"} {
+		if !strings.Contains(log, want) {
+			t.Errorf("log missing %q:\n%s", want, log)
+		}
+	}
+
+	// A page data change re-renders the page itself and the one listing .Pages
+	if err := os.WriteFile(filepath.Join(dir, "site.yaml"), []byte(`
+global:
+  data:
+    site_name: "Test"
+pages:
+  - template: "index.html"
+    output: "index.html"
+    data:
+      title: "Home"
+  - template: "stats.html"
+    output: "stats.html"
+  - template: "list.html"
+    output: "list.html"
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	log = buildWithLog(t, dir, false)
+	for _, want := range []string{"Generated: index.html", "Unchanged: stats.html", "Generated: list.html"} {
+		if !strings.Contains(log, want) {
+			t.Errorf("log missing %q:\n%s", want, log)
+		}
+	}
+}
+
+func TestSharedFieldsUsed(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		tmpl string
+		want []string
+	}{
+		{name: "page only", tmpl: `{{ .Page.title }}`, want: nil},
+		{name: "field", tmpl: `{{ .Global.site_name }}`, want: []string{"Global"}},
+		{name: "inside range", tmpl: `{{ range .Page.items }}{{ $.Static }}{{ end }}`, want: []string{"Static"}},
+		{name: "variable", tmpl: `{{ $root := . }}{{ with $root }}{{ $root.Feeds }}{{ end }}`, want: []string{"Feeds"}},
+		{name: "if else", tmpl: `{{ if .Page.x }}{{ else }}{{ .Data.y }}{{ end }}`, want: []string{"Data"}},
+		{name: "define", tmpl: `{{ define "list" }}{{ range .Pages }}{{ end }}{{ end }}{{ template "list" $ }}`, want: []string{"Pages"}},
+		{name: "dollar argument", tmpl: `{{ printf "%v" $ }}`, want: sharedFields},
+		{name: "dot argument", tmpl: `{{ printf "%v" . }}`, want: sharedFields},
+		{name: "dot variable", tmpl: `{{ $r := . }}{{ $r }}`, want: sharedFields},
+		{name: "dollar variable", tmpl: `{{ range .Page.items }}{{ $r := $ }}{{ printf "%v" $r }}{{ end }}`, want: sharedFields},
+		{name: "dot inside range", tmpl: `{{ range .Page.items }}{{ . }}{{ end }}`, want: nil},
+		{name: "dot inside with", tmpl: `{{ with .Global }}{{ . }}{{ end }}`, want: []string{"Global"}},
+		{name: "with root", tmpl: `{{ with $ }}{{ .Static }}{{ end }}{{ if . }}{{ end }}`, want: []string{"Static"}},
+		{name: "dot inside with root", tmpl: `{{ with $r := . }}{{ . }}{{ end }}`, want: sharedFields},
+		{name: "dot passed to template", tmpl: `{{ define "x" }}{{ .Page.title }}{{ end }}{{ template "x" . }}`, want: nil},
+		{name: "dot in defined template", tmpl: `{{ define "x" }}{{ . }}{{ end }}{{ template "x" .Page }}`, want: sharedFields},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tmpl := template.Must(template.New("page").Parse(tt.tmpl))
+			used := sharedFieldsUsed(tmpl)
+
+			for _, field := range sharedFields {
+				if want := slices.Contains(tt.want, field); used[field] != want {
+					t.Errorf("used[%s] = %v, want %v", field, used[field], want)
+				}
+			}
+		})
+	}
+}
+
+func TestBuild_IncrementalStatic(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	counter := filepath.Join(dir, "runs.log")
+
+	yaml := `
+pages:
+  - template: "index.html"
+    output: "index.html"
+
+static:
+  pipelines:
+    - match: "*.txt"
+      commands:
+        - "cp {{.Src}} {{.Dest}}"
+        - "echo run >> ` + counter + `"
+    - match: "*.css"
+      always: true
+      commands:
+        - "cp {{.Src}} {{.Dest}}"
+        - "echo always >> ` + counter + `"
+`
+
+	project := setupProject(t, yaml)
+
+	if err := os.WriteFile(filepath.Join(project, "templates", "index.html"), []byte(`ok`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	src := filepath.Join(project, "static", "data.txt")
+	if err := os.WriteFile(src, []byte("one"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(project, "static", "site.css"), []byte("body{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	count := func(marker string) int {
+		t.Helper()
+
+		data, err := os.ReadFile(counter)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return strings.Count(string(data), marker)
+	}
+
+	buildWithLog(t, project, false)
+	buildWithLog(t, project, false)
+
+	if n := count("run"); n != 1 {
+		t.Errorf("pipeline ran %d times, want 1", n)
+	}
+
+	if n := count("always"); n != 2 {
+		t.Errorf("always pipeline ran %d times, want 2", n)
+	}
+
+	if err := os.WriteFile(src, []byte("two!"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	buildWithLog(t, project, false)
+
+	if n := count("run"); n != 2 {
+		t.Errorf("pipeline ran %d times after change, want 2", n)
+	}
+
+	content, err := os.ReadFile(filepath.Join(project, "public", "data.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(content) != "two!" {
+		t.Errorf("data.txt = %q", content)
+	}
+}
+
+func TestBuildCache_Scan(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	cacheDir := filepath.Join(dir, "cache")
+	outDir := filepath.Join(dir, "out")
+
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	createTestImage(t, filepath.Join(outDir, "a.png"), 10, 20, "png")
+
+	cache := loadBuildCache(cacheDir, false)
+	if _, err := scanStaticFiles(outDir, 1, cache); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cache.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Tamper with the cached record to prove the second scan reuses it
+	cache = loadBuildCache(cacheDir, false)
+	rec := cache.prev.Scan["a.png"]
+	rec.Info.Width = 99
+	cache.prev.Scan["a.png"] = rec
+
+	meta, err := scanStaticFiles(outDir, 1, cache)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if meta["a.png"].Width != 99 {
+		t.Errorf("width = %d, want cached 99", meta["a.png"].Width)
+	}
+
+	// Force starts from an empty manifest
+	cache = loadBuildCache(cacheDir, true)
+
+	meta, err = scanStaticFiles(outDir, 1, cache)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if meta["a.png"].Width != 10 {
+		t.Errorf("width = %d, want scanned 10", meta["a.png"].Width)
+	}
+}
+
+func TestLoadBuildCache_Corrupt(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, manifestFile), []byte("{not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cache := loadBuildCache(dir, false)
+	if len(cache.prev.Pages) != 0 || cache.prev.Version != manifestVersion {
+		t.Errorf("corrupt manifest should be ignored: %+v", cache.prev)
+	}
+}
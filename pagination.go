@@ -0,0 +1,132 @@
+package ssssg
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+var errPaginateNotList = errors.New("paginated items must be a list")
+
+// Paginator is available to templates as .Paginator on paginated pages.
+type Paginator struct {
+	Items      []any // items on the current page
+	Number     int   // current page number, starting at 1
+	TotalPages int
+	TotalItems int
+	PerPage    int
+	HasPrev    bool
+	HasNext    bool
+	PrevURL    string
+	NextURL    string
+	FirstURL   string
+	LastURL    string
+}
+
+// outputURL returns the site-relative URL for an output path. Outputs named
+// index.html get pretty URLs: "blog/index.html" becomes "/blog/".
+func outputURL(output string) string {
+	p := path.Clean("/" + filepath.ToSlash(output))
+
+	if path.Base(p) != "index.html" {
+		return p
+	}
+
+	dir := path.Dir(p)
+	if dir == "/" {
+		return "/"
+	}
+
+	return dir + "/"
+}
+
+// paginatedOutput returns the output path of page number n. The first page
+// keeps the configured output; later pages go to <dir>/page/<n>/index.html.
+func paginatedOutput(output string, n int) string {
+	if n == 1 {
+		return output
+	}
+
+	return path.Join(path.Dir(filepath.ToSlash(output)), "page", strconv.Itoa(n), "index.html")
+}
+
+// isPaginatedOutput reports whether p is the output of page 2 or later of
+// a list written to output.
+func isPaginatedOutput(output, p string) bool {
+	rest, ok := strings.CutPrefix(p, path.Join(path.Dir(output), "page")+"/")
+	if !ok {
+		return false
+	}
+
+	number, file, _ := strings.Cut(rest, "/")
+	n, err := strconv.Atoi(number)
+
+	return err == nil && n >= 2 && file == "index.html"
+}
+
+// paginate splits items into pages of perPage items. An empty list still
+// yields one (empty) page so the list page itself is rendered.
+func paginate(items []any, perPage int, output string) []*Paginator {
+	total := max((len(items)+perPage-1)/perPage, 1)
+
+	firstURL := outputURL(paginatedOutput(output, 1))
+	lastURL := outputURL(paginatedOutput(output, total))
+
+	pagers := make([]*Paginator, 0, total)
+
+	for n := 1; n <= total; n++ {
+		start := (n - 1) * perPage
+		end := min(start+perPage, len(items))
+
+		p := &Paginator{
+			Items:      items[start:end:end],
+			Number:     n,
+			TotalPages: total,
+			TotalItems: len(items),
+			PerPage:    perPage,
+			HasPrev:    n > 1,
+			HasNext:    n < total,
+			FirstURL:   firstURL,
+			LastURL:    lastURL,
+		}
+
+		if p.HasPrev {
+			p.PrevURL = outputURL(paginatedOutput(output, n-1))
+		}
+
+		if p.HasNext {
+			p.NextURL = outputURL(paginatedOutput(output, n+1))
+		}
+
+		pagers = append(pagers, p)
+	}
+
+	return pagers
+}
+
+// paginationItems returns the list a page paginates: a key of its data or
+// the pages generated by a collection.
+func paginationItems(cfg *PaginateConfig, pageData map[string]any, collections map[string][]*PageInfo) ([]any, error) {
+	if cfg.Collection != "" {
+		generated := collections[cfg.Collection]
+		items := make([]any, 0, len(generated))
+
+		for _, info := range generated {
+			items = append(items, info)
+		}
+
+		return items, nil
+	}
+
+	v := pageData[cfg.Items]
+
+	items, ok := v.([]any)
+	if !ok {
+		return nil, fmt.Errorf("%s: %w, got %T", cfg.Items, errPaginateNotList, v)
+	}
+
+	return items, nil
+}
@@ -0,0 +1,257 @@
+package ssssg
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestOutputURL(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"index.html":             "/",
+		"blog/index.html":        "/blog/",
+		"blog/page/2/index.html": "/blog/page/2/",
+		"about.html":             "/about.html",
+		"./docs/guide.html":      "/docs/guide.html",
+	}
+
+	for output, want := range tests {
+		if got := outputURL(output); got != want {
+			t.Errorf("outputURL(%q) = %q, want %q", output, got, want)
+		}
+	}
+}
+
+func TestPaginatedOutput(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		output string
+		n      int
+		want   string
+	}{
+		{"blog/index.html", 1, "blog/index.html"},
+		{"blog/index.html", 2, "blog/page/2/index.html"},
+		{"index.html", 3, "page/3/index.html"},
+	}
+
+	for _, tt := range tests {
+		if got := paginatedOutput(tt.output, tt.n); got != tt.want {
+			t.Errorf("paginatedOutput(%q, %d) = %q, want %q", tt.output, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	t.Parallel()
+
+	items := []any{1, 2, 3, 4, 5}
+
+	pagers := paginate(items, 2, "blog/index.html")
+	if len(pagers) != 3 {
+		t.Fatalf("len(pagers) = %d, want 3", len(pagers))
+	}
+
+	first, middle, last := pagers[0], pagers[1], pagers[2]
+
+	if first.HasPrev || first.PrevURL != "" || !first.HasNext || first.NextURL != "/blog/page/2/" {
+		t.Errorf("first = %+v", first)
+	}
+
+	if middle.Number != 2 || middle.PrevURL != "/blog/" || middle.NextURL != "/blog/page/3/" {
+		t.Errorf("middle = %+v", middle)
+	}
+
+	if len(last.Items) != 1 || last.Items[0] != 5 || last.HasNext {
+		t.Errorf("last = %+v", last)
+	}
+
+	for _, p := range pagers {
+		if p.TotalPages != 3 || p.TotalItems != 5 || p.PerPage != 2 || p.FirstURL != "/blog/" || p.LastURL != "/blog/page/3/" {
+			t.Errorf("pager %d = %+v", p.Number, p)
+		}
+	}
+
+	empty := paginate(nil, 10, "blog/index.html")
+	if len(empty) != 1 || empty[0].TotalPages != 1 || len(empty[0].Items) != 0 {
+		t.Errorf("empty = %+v", empty)
+	}
+}
+
+func TestPaginationItems_NotList(t *testing.T) {
+	t.Parallel()
+
+	_, err := paginationItems(&PaginateConfig{Items: "posts"}, map[string]any{"posts": "nope"}, nil)
+	if !errors.Is(err, errPaginateNotList) {
+		t.Errorf("err = %v, want errPaginateNotList", err)
+	}
+
+	_, err = paginationItems(&PaginateConfig{Items: "missing"}, map[string]any{}, nil)
+	if !errors.Is(err, errPaginateNotList) {
+		t.Errorf("err = %v, want errPaginateNotList", err)
+	}
+}
+
+func TestBuild_WithPagination(t *testing.T) {
+	t.Parallel()
+
+	yaml := `
+pages:
+  - template: "list.html"
+    output: "blog/index.html"
+    data:
+      posts: ["a", "b", "c", "d", "e"]
+    paginate:
+      items: "posts"
+      per_page: 2
+  - template: "products.html"
+    output: "products/index.html"
+    paginate:
+      collection: "products"
+      per_page: 2
+
+collections:
+  - name: products
+    template: "product.html"
+    output: "products/{{ .slug }}.html"
+    data:
+      - slug: "x"
+      - slug: "y"
+      - slug: "z"
+`
+
+	dir := setupProject(t, yaml)
+
+	templates := map[string]string{
+		"list.html": `{{ with .Paginator }}{{ .Number }}/{{ .TotalPages }}:` +
+			`{{ range .Items }}[{{ . }}]{{ end }}` +
+			` prev={{ .PrevURL }} next={{ .NextURL }}{{ end }}`,
+		"products.html": `{{ range .Paginator.Items }}<a href="{{ .URL }}">{{ .Data.slug }}</a>{{ end }}`,
+		"product.html":  `{{ .Page.slug }}`,
+	}
+	for name, content := range templates {
+		if err := os.WriteFile(filepath.Join(dir, "templates", name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := Build(t.Context(), BuildOptions{
+		ConfigPath: filepath.Join(dir, "site.yaml"),
+		Timeout:    10 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	want := map[string]string{
+		"blog/index.html":            "1/3:[a][b] prev= next=/blog/page/2/",
+		"blog/page/2/index.html":     "2/3:[c][d] prev=/blog/ next=/blog/page/3/",
+		"blog/page/3/index.html":     "3/3:[e] prev=/blog/page/2/ next=",
+		"products/index.html":        `<a href="/products/x.html">x</a><a href="/products/y.html">y</a>`,
+		"products/page/2/index.html": `<a href="/products/z.html">z</a>`,
+	}
+	for output, expected := range want {
+		content, err := os.ReadFile(filepath.Join(dir, "public", output))
+		if err != nil {
+			t.Fatalf("%s not generated: %v", output, err)
+		}
+
+		if string(content) != expected {
+			t.Errorf("%s = %q, want %q", output, content, expected)
+		}
+	}
+}
+
+func TestBuild_OutputCollisions(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("file"))
+	}))
+	t.Cleanup(srv.Close)
+
+	paginated := func(output string) string {
+		return `
+  - template: "t.html"
+    output: "` + output + `"
+    data:
+      items: [1, 2, 3]
+    paginate:
+      items: "items"
+      per_page: 1`
+	}
+
+	tests := []struct {
+		name string
+		yaml string
+	}{
+		{
+			name: "two paginated pages in one directory",
+			yaml: "pages:" + paginated("blog/index.html") + paginated("blog/archive.html"),
+		},
+		{
+			name: "two paginated root pages",
+			yaml: "pages:" + paginated("index.html") + paginated("blog.html"),
+		},
+		{
+			name: "paginated page and page",
+			yaml: "pages:" + paginated("blog/index.html") + `
+  - template: "t.html"
+    output: "blog/page/3/index.html"`,
+		},
+		{
+			name: "paginated page and collection",
+			yaml: "pages:" + paginated("index.html") + `
+collections:
+  - template: "t.html"
+    output: "page/{{ .n }}/index.html"
+    data:
+      - n: 2`,
+		},
+		{
+			name: "sitemap",
+			yaml: "global:\n  base_url: \"https://example.com\"\nsitemap: {}\npages:" + `
+  - template: "t.html"
+    output: "sitemap.xml"`,
+		},
+		{
+			name: "feed",
+			yaml: "global:\n  base_url: \"https://example.com\"\nfeeds:\n  - name: all\n    pages: \"*\"\n    rss: \"index.html\"\npages:" + `
+  - template: "t.html"
+    output: "index.html"`,
+		},
+		{
+			name: "download",
+			yaml: "pages:" + paginated("index.html") + `
+    fetch:
+      file:
+        url: "` + srv.URL + `"
+        download: "page/2/index.html"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := setupProject(t, tt.yaml)
+			if err := os.WriteFile(filepath.Join(dir, "templates", "t.html"), []byte("x"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			err := Build(t.Context(), BuildOptions{
+				ConfigPath: filepath.Join(dir, "site.yaml"),
+				Timeout:    10 * time.Second,
+			})
+			if !errors.Is(err, errDuplicateOutput) {
+				t.Errorf("err = %v, want errDuplicateOutput", err)
+			}
+		})
+	}
+}
@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"io/fs"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"text/template"
@@ -28,6 +27,12 @@ type PipelineData struct {
 // Files matching a pipeline have their commands executed in order.
 // Unmatched files are copied using copyFile.
 func ProcessStatic(ctx context.Context, staticDir, outputDir string, pipelines []PipelineConfig, parallelism int) error {
+	return processStatic(ctx, staticDir, outputDir, pipelines, parallelism, nil)
+}
+
+// processStatic is ProcessStatic with an optional build cache. Files whose
+// source and pipeline are unchanged since the previous build are skipped.
+func processStatic(ctx context.Context, staticDir, outputDir string, pipelines []PipelineConfig, parallelism int, cache *buildCache) error {
 	info, err := os.Stat(staticDir)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -44,6 +49,7 @@ func ProcessStatic(ctx context.Context, staticDir, outputDir string, pipelines [
 	type fileEntry struct {
 		path    string
 		relPath string
+		info    fs.FileInfo
 	}
 
 	var files []fileEntry
@@ -67,7 +73,12 @@ func ProcessStatic(ctx context.Context, staticDir, outputDir string, pipelines [
 			return fmt.Errorf("relative path: %w", err)
 		}
 
-		files = append(files, fileEntry{path: path, relPath: relPath})
+		fi, err := d.Info()
+		if err != nil {
+			return fmt.Errorf("stat %s: %w", relPath, err)
+		}
+
+		files = append(files, fileEntry{path: path, relPath: relPath, info: fi})
 
 		return nil
 	})
@@ -81,12 +92,19 @@ func ProcessStatic(ctx context.Context, staticDir, outputDir string, pipelines [
 	for _, f := range files {
 		g.Go(func() error {
 			destPath := filepath.Join(outputDir, f.relPath)
+			pipeline := matchPipeline(f.relPath, pipelines)
+
+			// Incremental builds only see the source file; pipelines that read
+			// other inputs opt out with always.
+			fresh := cache.staticFresh(filepath.ToSlash(f.relPath), staticFingerprint(f.info, pipeline), destPath)
+			if fresh && (pipeline == nil || !pipeline.Always) {
+				return nil
+			}
 
 			if err := os.MkdirAll(filepath.Dir(destPath), 0o755); err != nil {
 				return fmt.Errorf("create dir for %s: %w", f.relPath, err)
 			}
 
-			pipeline := matchPipeline(f.relPath, pipelines)
 			if pipeline == nil {
 				return copyFile(f.path, destPath)
 			}
@@ -161,7 +179,7 @@ func runCommand(ctx context.Context, cmdTemplate string, data PipelineData) erro
 
 	rendered := buf.String()
 
-	cmd := exec.CommandContext(ctx, "sh", "-c", rendered)
+	cmd := shellCommand(ctx, rendered)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 
@@ -0,0 +1,246 @@
+package ssssg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var errEnvUnset = errors.New("environment variable is not set")
+
+const redacted = "REDACTED"
+
+//nolint:gochecknoglobals
+var validMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete,
+}
+
+// sensitiveParams are query parameter name fragments whose values are
+// hidden in logs and errors.
+//
+//nolint:gochecknoglobals
+var sensitiveParams = []string{"token", "key", "secret", "password", "passwd", "auth", "signature", "sig", "credential"}
+
+func isRemote(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
+
+// httpMethod returns the HTTP method, GET by default or POST when a body is
+// set or the entry is a GraphQL query.
+func (e FetchEntry) httpMethod() string {
+	switch {
+	case e.Method != "":
+		return strings.ToUpper(e.Method)
+	case e.Body != "" || e.Type == FetchTypeGraphQL:
+		return http.MethodPost
+	default:
+		return http.MethodGet
+	}
+}
+
+// hasRequestOptions reports whether the entry sets any HTTP request option.
+func (e FetchEntry) hasRequestOptions() bool {
+	return e.Method != "" || len(e.Headers) > 0 || e.Body != "" || len(e.Query) > 0 || e.Type != "" || e.Follow != nil
+}
+
+// cacheKey identifies the response of an entry. It uses the unexpanded
+// option values plus a hash of their expansions, so that requests made
+// with different environment values do not share a response while secrets
+// never end up in cache keys or file names. A plain URL is its own key.
+// The encoding is part of the key because responses are cached after
+// conversion to UTF-8, the download path because each download is restored
+// from its own copy, and the body size limit because a response may fit
+// one limit and not another.
+func (e FetchEntry) cacheKey() string {
+	if e.Exec != nil {
+		return hashStrings(append([]string{"exec", e.Encoding, strconv.FormatInt(int64(e.MaxBodySize), 10), e.Exec.Shell}, e.Exec.Args...)...)
+	}
+
+	if !e.hasRequestOptions() && e.Encoding == "" && e.Download == "" && e.MaxBodySize == 0 {
+		return e.URL
+	}
+
+	parts := []string{e.URL, e.httpMethod(), e.Body, e.Type, e.GraphQL, e.GraphQLFile, hashJSON(e.Variables), hashJSON(e.Follow), e.Encoding, e.Download, strconv.FormatInt(int64(e.MaxBodySize), 10)}
+
+	body := e.Body
+	if !e.literalBody {
+		body = expandedForKey(body)
+	}
+
+	expanded := []string{body}
+
+	for _, k := range sortedKeys(e.Headers) {
+		parts = append(parts, "header", http.CanonicalHeaderKey(k), e.Headers[k])
+		expanded = append(expanded, expandedForKey(e.Headers[k]))
+	}
+
+	for _, k := range sortedKeys(e.Query) {
+		parts = append(parts, "query", k, e.Query[k])
+		expanded = append(expanded, expandedForKey(e.Query[k]))
+	}
+
+	return hashStrings(append(parts, hashStrings(expanded...))...)
+}
+
+// expandedForKey returns a request value as sent. Values referencing unset
+// variables are kept as they are since the request itself fails.
+func expandedForKey(s string) string {
+	v, err := expandEnv(s)
+	if err != nil {
+		return s
+	}
+
+	return v
+}
+
+// describe returns the entry for log lines: the command of exec entries, or
+// the method when it is not GET and the URL with credentials and sensitive
+// query values redacted. Header values and the body are never shown.
+func (e FetchEntry) describe() string {
+	if e.Exec != nil {
+		return "exec: " + e.Exec.String()
+	}
+
+	if !isRemote(e.URL) {
+		return e.URL
+	}
+
+	s := redactURL(e.URL)
+	if m := e.httpMethod(); m != http.MethodGet {
+		s = m + " " + s
+	}
+
+	return s
+}
+
+// newRequest builds the HTTP request of a remote entry. Header, query and
+// body values may reference environment variables as ${NAME} or $NAME; an
+// unset variable is an error rather than an empty credential. $$ is a
+// literal $.
+func (e FetchEntry) newRequest(ctx context.Context) (*http.Request, error) {
+	u, err := url.Parse(e.URL)
+	if err != nil {
+		return nil, fmt.Errorf("parse url %s: %w", redactURL(e.URL), err)
+	}
+
+	if len(e.Query) > 0 {
+		q := u.Query()
+
+		for _, k := range sortedKeys(e.Query) {
+			v, err := expandEnv(e.Query[k])
+			if err != nil {
+				return nil, fmt.Errorf("query %s: %w", k, err)
+			}
+
+			q.Set(k, v)
+		}
+
+		u.RawQuery = q.Encode()
+	}
+
+	var body io.Reader
+
+	if e.Body != "" {
+		b := e.Body
+
+		if !e.literalBody {
+			b, err = expandEnv(e.Body)
+			if err != nil {
+				return nil, fmt.Errorf("body: %w", err)
+			}
+		}
+
+		body = strings.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, e.httpMethod(), u.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("create request for %s: %w", redactURL(e.URL), err)
+	}
+
+	for _, k := range sortedKeys(e.Headers) {
+		v, err := expandEnv(e.Headers[k])
+		if err != nil {
+			return nil, fmt.Errorf("header %s: %w", k, err)
+		}
+
+		req.Header.Set(k, v)
+	}
+
+	return req, nil
+}
+
+// expandEnv replaces ${NAME} and $NAME with environment variables and $$
+// with a literal $, as in {"$$match": ...}.
+func expandEnv(s string) (string, error) {
+	var missing []string
+
+	parts := strings.Split(s, "$$")
+	for i, part := range parts {
+		parts[i] = os.Expand(part, func(name string) string {
+			v, ok := os.LookupEnv(name)
+			if !ok {
+				missing = append(missing, name)
+			}
+
+			return v
+		})
+	}
+
+	if len(missing) > 0 {
+		return "", fmt.Errorf("%w: %s", errEnvUnset, strings.Join(missing, ", "))
+	}
+
+	return strings.Join(parts, "$"), nil
+}
+
+// redactURL hides the userinfo password and the values of sensitive query
+// parameters.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+
+	q := u.Query()
+	changed := false
+
+	for k := range q {
+		if isSensitiveParam(k) {
+			q.Set(k, redacted)
+			changed = true
+		}
+	}
+
+	if changed {
+		u.RawQuery = q.Encode()
+	}
+
+	return u.Redacted()
+}
+
+func isSensitiveParam(name string) bool {
+	name = strings.ToLower(name)
+
+	return slices.ContainsFunc(sensitiveParams, func(s string) bool {
+		return strings.Contains(name, s)
+	})
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	slices.Sort(keys)
+
+	return keys
+}
@@ -0,0 +1,123 @@
+package ssssg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// maxBackoff caps the exponential backoff between retries.
+const maxBackoff = 30 * time.Second
+
+// defaultRetryStatuses are the HTTP statuses retried when no list is configured.
+//
+//nolint:gochecknoglobals
+var defaultRetryStatuses = []int{
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// RetryPolicy controls how failed HTTP fetches are retried. Network errors,
+// request timeouts and the listed statuses are retried up to Retries times.
+// The delay starts at Backoff and doubles after every attempt; a Retry-After
+// header from the server takes precedence. Both are capped at 30 seconds.
+type RetryPolicy struct {
+	Retries  int
+	Backoff  time.Duration
+	Statuses []int // defaults to 429, 500, 502, 503 and 504
+}
+
+func (p RetryPolicy) retryableStatus(code int) bool {
+	statuses := p.Statuses
+	if len(statuses) == 0 {
+		statuses = defaultRetryStatuses
+	}
+
+	return slices.Contains(statuses, code)
+}
+
+// delay returns how long to wait before retry number attempt (starting at 0).
+func (p RetryPolicy) delay(attempt int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return min(retryAfter, maxBackoff)
+	}
+
+	d := p.Backoff
+	for range attempt {
+		d *= 2
+		if d >= maxBackoff {
+			return maxBackoff
+		}
+	}
+
+	return d
+}
+
+// statusError is returned for responses with an unexpected status.
+type statusError struct {
+	code       int
+	retryAfter time.Duration
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("%v: %d", errHTTPStatus, e.code)
+}
+
+func (e *statusError) Unwrap() error {
+	return errHTTPStatus
+}
+
+// retryable reports whether a failed attempt may succeed when repeated, and
+// how long the server asked to wait.
+func (p RetryPolicy) retryable(err error) (bool, time.Duration) {
+	var se *statusError
+	if errors.As(err, &se) {
+		return p.retryableStatus(se.code), se.retryAfter
+	}
+
+	// Only transport failures may go away on their own. Anything else, such
+	// as an unset variable, an invalid URL or a host that does not exist,
+	// fails the same way every time.
+	var (
+		netErr net.Error
+		dnsErr *net.DNSError
+	)
+
+	switch {
+	case errors.As(err, &dnsErr) && dnsErr.IsNotFound:
+		return false, 0
+	case errors.As(err, &netErr),
+		errors.Is(err, io.ErrUnexpectedEOF),
+		errors.Is(err, context.DeadlineExceeded): // the per-request timeout
+		return true, 0
+	default:
+		return false, 0
+	}
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an
+// HTTP date.
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+
+	if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0)
+	}
+
+	return 0
+}
@@ -0,0 +1,216 @@
+package ssssg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	t.Parallel()
+
+	p := RetryPolicy{Backoff: time.Second}
+
+	tests := []struct {
+		attempt    int
+		retryAfter time.Duration
+		want       time.Duration
+	}{
+		{0, 0, time.Second},
+		{1, 0, 2 * time.Second},
+		{3, 0, 8 * time.Second},
+		{10, 0, maxBackoff},
+		{1, 5 * time.Second, 5 * time.Second},
+		{1, time.Hour, maxBackoff},
+	}
+
+	for _, tt := range tests {
+		if got := p.delay(tt.attempt, tt.retryAfter); got != tt.want {
+			t.Errorf("delay(%d, %s) = %s, want %s", tt.attempt, tt.retryAfter, got, tt.want)
+		}
+	}
+}
+
+func TestRetryPolicy_Retryable(t *testing.T) {
+	t.Parallel()
+
+	p := RetryPolicy{}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"retryable status", fmt.Errorf("fetch: %w", &statusError{code: http.StatusServiceUnavailable}), true},
+		{"other status", &statusError{code: http.StatusNotFound}, false},
+		{"network error", fmt.Errorf("fetch: %w", &net.OpError{Op: "dial", Err: errors.New("refused")}), true},
+		{"unknown host", fmt.Errorf("fetch: %w", &net.OpError{Op: "dial", Err: &net.DNSError{Err: "no such host", Name: "typo.invalid", IsNotFound: true}}), false},
+		{"dns timeout", fmt.Errorf("fetch: %w", &net.DNSError{Err: "i/o timeout", Name: "example.com", IsTimeout: true}), true},
+		{"truncated body", fmt.Errorf("read: %w", io.ErrUnexpectedEOF), true},
+		{"request timeout", fmt.Errorf("fetch: %w", context.DeadlineExceeded), true},
+		{"canceled", context.Canceled, false},
+		{"body too large", fmt.Errorf("fetch: %w", errBodyTooLarge), false},
+		{"unset variable", fmt.Errorf("header: %w", errEnvUnset), false},
+	}
+
+	for _, tt := range tests {
+		if got, _ := p.retryable(tt.err); got != tt.want {
+			t.Errorf("%s: retryable = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFetcher_RetryPastDeadline(t *testing.T) {
+	t.Parallel()
+
+	var hits atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Retry-After", "20")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	f := NewFetcher("", srv.Client(), WithRetry(RetryPolicy{Retries: 2, Backoff: time.Millisecond}))
+
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+
+	start := time.Now()
+
+	_, err := f.Fetch(ctx, srv.URL)
+	if !errors.Is(err, errHTTPStatus) {
+		t.Errorf("err = %v, want errHTTPStatus", err)
+	}
+
+	if hits.Load() != 1 || time.Since(start) > time.Second {
+		t.Errorf("%d hit(s) in %s, want 1 without waiting", hits.Load(), time.Since(start))
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	if got := parseRetryAfter("3"); got != 3*time.Second {
+		t.Errorf("seconds = %s, want 3s", got)
+	}
+
+	date := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	if got := parseRetryAfter(date); got <= 50*time.Second || got > time.Minute {
+		t.Errorf("date = %s, want about 1m", got)
+	}
+
+	if got := parseRetryAfter("soon"); got != 0 {
+		t.Errorf("invalid = %s, want 0", got)
+	}
+}
+
+func TestFetcher_Retry(t *testing.T) {
+	t.Parallel()
+
+	var hits atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if hits.Add(1) <= 2 {
+			w.WriteHeader(http.StatusBadGateway)
+
+			return
+		}
+
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	f := NewFetcher("", srv.Client(), WithRetry(RetryPolicy{Retries: 2, Backoff: time.Millisecond}))
+
+	content, err := f.Fetch(t.Context(), srv.URL)
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+
+	if content != "ok" || hits.Load() != 3 {
+		t.Errorf("content = %q after %d hits, want ok after 3", content, hits.Load())
+	}
+}
+
+func TestFetcher_RetryGivesUp(t *testing.T) {
+	t.Parallel()
+
+	var hits atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	f := NewFetcher("", srv.Client(), WithRetry(RetryPolicy{Retries: 2, Backoff: time.Millisecond}))
+
+	_, err := f.Fetch(t.Context(), srv.URL)
+	if !errors.Is(err, errHTTPStatus) {
+		t.Errorf("err = %v, want errHTTPStatus", err)
+	}
+
+	if got := hits.Load(); got != 3 {
+		t.Errorf("hits = %d, want 3", got)
+	}
+
+	// 404 is not retryable
+	_, err = f.Fetch(t.Context(), srv.URL+"/missing")
+	if !errors.Is(err, errHTTPStatus) {
+		t.Errorf("err = %v, want errHTTPStatus", err)
+	}
+
+	if got := hits.Load(); got != 4 {
+		t.Errorf("hits = %d, want 4", got)
+	}
+}
+
+func TestFetcher_RequestTimeout(t *testing.T) {
+	t.Parallel()
+
+	var hits atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) == 1 {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+
+			return
+		}
+
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	f := NewFetcher("", srv.Client(),
+		WithRequestTimeout(50*time.Millisecond),
+		WithRetry(RetryPolicy{Retries: 1, Backoff: time.Millisecond}),
+	)
+
+	content, err := f.Fetch(t.Context(), srv.URL)
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+
+	if content != "ok" {
+		t.Errorf("content = %q, want ok", content)
+	}
+}
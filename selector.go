@@ -0,0 +1,95 @@
+package ssssg
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+)
+
+// Parts of the nodes matched by a selector that are kept.
+const (
+	ExtractOuter = "outer" // the matched elements themselves (default)
+	ExtractInner = "inner" // the HTML inside the matched elements
+	ExtractText  = "text"  // the text content of the matched elements
+)
+
+var (
+	errSelectorInvalid = errors.New("invalid CSS selector")
+	errSelectNoMatch   = errors.New("selector matched no elements")
+	errExtractInvalid  = errors.New("extract must be outer, inner or text")
+	errSelectOptions   = errors.New("select only applies to raw HTML, not to formats, graphql or follow")
+)
+
+// selectHTML parses an HTML document and returns the part of the nodes that
+// match selector, one match per line. No match is an error so that a changed
+// page does not silently render nothing.
+func selectHTML(body, selector, extract string) (string, error) {
+	sel, err := cascadia.Compile(selector)
+	if err != nil {
+		return "", fmt.Errorf("%w %q: %w", errSelectorInvalid, selector, err)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("parse html: %w", err)
+	}
+
+	matches := doc.FindMatcher(sel)
+	if matches.Length() == 0 {
+		return "", fmt.Errorf("%w: %s", errSelectNoMatch, selector)
+	}
+
+	parts := make([]string, 0, matches.Length())
+
+	for _, node := range matches.EachIter() {
+		var part string
+
+		switch extract {
+		case ExtractInner:
+			part, err = node.Html()
+		case ExtractText:
+			part = strings.TrimSpace(node.Text())
+		default:
+			part, err = goquery.OuterHtml(node)
+		}
+
+		if err != nil {
+			return "", fmt.Errorf("render html: %w", err)
+		}
+
+		parts = append(parts, part)
+	}
+
+	return strings.Join(parts, "\n"), nil
+}
+
+func validateSelect(entry FetchEntry) error {
+	if entry.Select == "" {
+		if entry.Extract != "" {
+			return fmt.Errorf("%w: extract without select", errSelectOptions)
+		}
+
+		return nil
+	}
+
+	if _, err := cascadia.Compile(entry.Select); err != nil {
+		return fmt.Errorf("%w %q: %w", errSelectorInvalid, entry.Select, err)
+	}
+
+	switch entry.Extract {
+	case "", ExtractOuter, ExtractInner, ExtractText:
+	default:
+		return fmt.Errorf("%w: %s", errExtractInvalid, entry.Extract)
+	}
+
+	switch {
+	case entry.Format != "" && entry.Format != FormatRaw && entry.Format != FormatAuto,
+		entry.Type != "", entry.Follow != nil:
+		return errSelectOptions
+	}
+
+	return nil
+}
@@ -0,0 +1,118 @@
+package ssssg
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const selectorPage = `<!DOCTYPE html>
+<html><head><title>Corporate</title></head>
+<body>
+  <div class="banner"><p>Sale <b>today</b></p></div>
+  <ul id="news"><li>One</li><li>Two</li></ul>
+  <footer>  &copy; Example  </footer>
+</body></html>`
+
+func TestSelectHTML(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		selector string
+		extract  string
+		want     string
+	}{
+		{
+			name:     "outer by default",
+			selector: ".banner",
+			want:     `<div class="banner"><p>Sale <b>today</b></p></div>`,
+		},
+		{
+			name:     "inner",
+			selector: ".banner",
+			extract:  ExtractInner,
+			want:     `<p>Sale <b>today</b></p>`,
+		},
+		{
+			name:     "text",
+			selector: "footer",
+			extract:  ExtractText,
+			want:     "© Example",
+		},
+		{
+			name:     "every match",
+			selector: "#news li",
+			extract:  ExtractOuter,
+			want:     "<li>One</li>\n<li>Two</li>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := selectHTML(selectorPage, tt.selector, tt.extract)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectHTML_NoMatch(t *testing.T) {
+	t.Parallel()
+
+	_, err := selectHTML(selectorPage, ".missing", "")
+	if !errors.Is(err, errSelectNoMatch) {
+		t.Errorf("expected errSelectNoMatch, got %v", err)
+	}
+}
+
+func TestFetcher_ResolveSelect(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(selectorPage))
+	}))
+	defer srv.Close()
+
+	f := NewFetcher(t.TempDir(), srv.Client())
+
+	got, err := f.Resolve(context.Background(), FetchEntry{URL: srv.URL, Select: ".banner b", Extract: ExtractText})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got != "today" {
+		t.Errorf("got %q, want %q", got, "today")
+	}
+}
+
+func TestValidateSelect(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		entry FetchEntry
+		want  error
+	}{
+		{FetchEntry{Select: "div.banner > p", Extract: ExtractInner}, nil},
+		{FetchEntry{Select: "div[", Extract: ExtractInner}, errSelectorInvalid},
+		{FetchEntry{Select: "div", Extract: "html"}, errExtractInvalid},
+		{FetchEntry{Select: "div", Format: FormatJSON}, errSelectOptions},
+		{FetchEntry{Extract: ExtractText}, errSelectOptions},
+	}
+
+	for _, tt := range tests {
+		if err := validateSelect(tt.entry); !errors.Is(err, tt.want) {
+			t.Errorf("validateSelect(%+v) = %v, want %v", tt.entry, err, tt.want)
+		}
+	}
+}
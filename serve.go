@@ -0,0 +1,383 @@
+package ssssg
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// liveReloadPath is the Server-Sent Events endpoint browsers subscribe to.
+const liveReloadPath = "/__ssssg/livereload"
+
+// liveReloadScript is injected into every served HTML page.
+const liveReloadScript = `<script>(function(){` +
+	`var es=new EventSource("` + liveReloadPath + `");` +
+	`es.addEventListener("reload",function(){location.reload();});` +
+	`})();</script>`
+
+//nolint:gochecknoglobals
+var errorPageTemplate = template.Must(template.New("error").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>ssssg: build failed</title></head>
+<body style="font-family:sans-serif;margin:2em">
+<h1 style="color:#c00">Build failed</h1>
+<pre style="white-space:pre-wrap;background:#fee;padding:1em">{{ . }}</pre>
+</body>
+</html>
+`))
+
+type ServeOptions struct {
+	BuildOptions
+
+	Addr         string        // listen address, defaults to localhost:8080
+	PollInterval time.Duration // how often watched files are checked, defaults to 500ms
+}
+
+// Serve builds the site, serves the output directory over HTTP and rebuilds
+// whenever the config, templates, static, content or data files change. Connected
+// browsers are reloaded after every rebuild. Build errors are rendered in the
+// browser instead of stopping the server. Serve returns when ctx is canceled.
+func Serve(ctx context.Context, opts ServeOptions) error {
+	if opts.Addr == "" {
+		opts.Addr = "localhost:8080"
+	}
+
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = 500 * time.Millisecond
+	}
+
+	opts.applyDefaults()
+
+	s := newDevServer(opts)
+	s.rebuild(ctx)
+
+	// --force applies to the initial build; rebuilds on change stay incremental
+	s.opts.Force = false
+
+	ln, err := net.Listen("tcp", opts.Addr)
+	if err != nil {
+		return fmt.Errorf("listen %s: %w", opts.Addr, err)
+	}
+
+	srv := &http.Server{
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	srv.RegisterOnShutdown(s.close)
+
+	s.logf("Serving %s at http://%s/", opts.OutputDir, ln.Addr())
+
+	go s.watch(ctx)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.Serve(ln)
+	}()
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("serve: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("shutdown: %w", err)
+	}
+
+	if err := <-errCh; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("serve: %w", err)
+	}
+
+	return nil
+}
+
+// devServer serves the output directory and notifies browsers about rebuilds.
+type devServer struct {
+	opts ServeOptions
+
+	mu       sync.RWMutex
+	buildErr error
+	clients  map[chan struct{}]struct{}
+	done     chan struct{}
+	doneOnce sync.Once
+}
+
+func newDevServer(opts ServeOptions) *devServer {
+	return &devServer{
+		opts:    opts,
+		clients: make(map[chan struct{}]struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+func (s *devServer) logf(format string, args ...any) {
+	if s.opts.Log != nil {
+		fmt.Fprintf(s.opts.Log, format+"\n", args...)
+	}
+}
+
+// rebuild runs a full build and records its error for the browser.
+func (s *devServer) rebuild(ctx context.Context) {
+	err := Build(ctx, s.opts.BuildOptions)
+	if err != nil {
+		s.logf("Build failed: %v", err)
+	}
+
+	s.mu.Lock()
+	s.buildErr = err
+	s.mu.Unlock()
+}
+
+// watch polls the watched paths and rebuilds when any of them changes.
+func (s *devServer) watch(ctx context.Context) {
+	ticker := time.NewTicker(s.opts.PollInterval)
+	defer ticker.Stop()
+
+	prev := snapshotFiles(s.watchPaths()...)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		cur := snapshotFiles(s.watchPaths()...)
+		if !snapshotChanged(prev, cur) {
+			continue
+		}
+
+		prev = cur
+
+		s.logf("Change detected, rebuilding...")
+		s.rebuild(ctx)
+		s.broadcast()
+	}
+}
+
+func (s *devServer) watchPaths() []string {
+	// Watch the default content directory too: adding a content section
+	// to site.yaml starts loading it.
+	contentDir := cmp.Or(s.opts.ContentDir, filepath.Join(filepath.Dir(s.opts.ConfigPath), "content"))
+
+	return []string{s.opts.ConfigPath, s.opts.TemplateDir, s.opts.StaticDir, contentDir, s.opts.DataDir}
+}
+
+// broadcast tells every connected browser to reload.
+func (s *devServer) broadcast() {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for ch := range s.clients {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// close disconnects all live reload clients so the server can shut down.
+func (s *devServer) close() {
+	s.doneOnce.Do(func() {
+		close(s.done)
+	})
+}
+
+func (s *devServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == liveReloadPath {
+		s.serveEvents(w, r)
+
+		return
+	}
+
+	s.mu.RLock()
+	buildErr := s.buildErr
+	s.mu.RUnlock()
+
+	urlPath := path.Clean("/" + r.URL.Path)
+	filePath := filepath.Join(s.opts.OutputDir, filepath.FromSlash(urlPath))
+
+	if info, err := os.Stat(filePath); err == nil && info.IsDir() {
+		// Like http.FileServer, so that relative links resolve in the directory
+		if !strings.HasSuffix(r.URL.Path, "/") {
+			target := urlPath + "/"
+			if r.URL.RawQuery != "" {
+				target += "?" + r.URL.RawQuery
+			}
+
+			http.Redirect(w, r, target, http.StatusMovedPermanently)
+
+			return
+		}
+
+		filePath = filepath.Join(filePath, "index.html")
+	}
+
+	isHTML := strings.HasSuffix(filePath, ".html")
+
+	if buildErr != nil && (isHTML || strings.Contains(r.Header.Get("Accept"), "text/html")) {
+		s.serveError(w, buildErr)
+
+		return
+	}
+
+	if !isHTML {
+		http.ServeFile(w, r, filePath)
+
+		return
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			http.NotFound(w, r)
+
+			return
+		}
+
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	_, _ = w.Write(injectLiveReload(content))
+}
+
+func (s *devServer) serveError(w http.ResponseWriter, buildErr error) {
+	var buf bytes.Buffer
+	if err := errorPageTemplate.Execute(&buf, buildErr.Error()); err != nil {
+		http.Error(w, buildErr.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusInternalServerError)
+	_, _ = w.Write(injectLiveReload(buf.Bytes()))
+}
+
+// serveEvents streams reload notifications as Server-Sent Events.
+func (s *devServer) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+
+		return
+	}
+
+	ch := make(chan struct{}, 1)
+
+	s.mu.Lock()
+	s.clients[ch] = struct{}{}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.clients, ch)
+		s.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.done:
+			return
+		case <-ch:
+			if _, err := fmt.Fprint(w, "event: reload\ndata: {}\n\n"); err != nil {
+				return
+			}
+
+			flusher.Flush()
+		}
+	}
+}
+
+// injectLiveReload inserts the live reload script before </body>, or appends
+// it when the document has no closing body tag.
+func injectLiveReload(content []byte) []byte {
+	idx := bytes.LastIndex(bytes.ToLower(content), []byte("</body>"))
+	if idx < 0 {
+		return append(content, liveReloadScript...)
+	}
+
+	out := make([]byte, 0, len(content)+len(liveReloadScript))
+	out = append(out, content[:idx]...)
+	out = append(out, liveReloadScript...)
+	out = append(out, content[idx:]...)
+
+	return out
+}
+
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// snapshotFiles records modification time and size of every file under the
+// given paths. Missing paths are skipped.
+func snapshotFiles(paths ...string) map[string]fileStamp {
+	snap := make(map[string]fileStamp)
+
+	for _, root := range paths {
+		_ = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil //nolint:nilerr // unreadable entries are not watched
+			}
+
+			if d.IsDir() {
+				return nil
+			}
+
+			info, err := d.Info()
+			if err != nil {
+				return nil //nolint:nilerr // file vanished during the walk
+			}
+
+			snap[p] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+
+			return nil
+		})
+	}
+
+	return snap
+}
+
+// snapshotChanged reports whether any file was added, removed or modified.
+func snapshotChanged(prev, cur map[string]fileStamp) bool {
+	if len(prev) != len(cur) {
+		return true
+	}
+
+	for p, stamp := range cur {
+		old, ok := prev[p]
+		if !ok || !old.modTime.Equal(stamp.modTime) || old.size != stamp.size {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,277 @@
+package ssssg
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func setupServeProject(t *testing.T, tmpl string) ServeOptions {
+	t.Helper()
+
+	yaml := `
+pages:
+  - template: "index.html"
+    output: "index.html"
+    data:
+      title: "Home"
+`
+
+	dir := setupProject(t, yaml)
+
+	if err := os.WriteFile(filepath.Join(dir, "templates", "index.html"), []byte(tmpl), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := ServeOptions{
+		BuildOptions: BuildOptions{
+			ConfigPath: filepath.Join(dir, "site.yaml"),
+			Timeout:    10 * time.Second,
+		},
+		PollInterval: 20 * time.Millisecond,
+	}
+	opts.applyDefaults()
+
+	return opts
+}
+
+func TestInjectLiveReload(t *testing.T) {
+	t.Parallel()
+
+	got := string(injectLiveReload([]byte("<html><body><p>hi</p></BODY></html>")))
+	want := "<html><body><p>hi</p>" + liveReloadScript + "</BODY></html>"
+
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	got = string(injectLiveReload([]byte("<p>fragment</p>")))
+	if got != "<p>fragment</p>"+liveReloadScript {
+		t.Errorf("script not appended: %q", got)
+	}
+}
+
+func TestDevServer_InjectsReloadScript(t *testing.T) {
+	t.Parallel()
+
+	opts := setupServeProject(t, `<html><body>{{ .Page.title }}</body></html>`)
+
+	if err := os.WriteFile(filepath.Join(filepath.Dir(opts.ConfigPath), "static", "style.css"), []byte("body{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := newDevServer(opts)
+	s.rebuild(t.Context())
+
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	resp, err := srv.Client().Get(srv.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d", resp.StatusCode)
+	}
+
+	if !strings.Contains(string(body), "Home"+liveReloadScript+"</body>") {
+		t.Errorf("reload script not injected:\n%s", body)
+	}
+
+	css, err := srv.Client().Get(srv.URL + "/style.css")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer css.Body.Close()
+
+	cssBody, _ := io.ReadAll(css.Body)
+	if string(cssBody) != "body{}" {
+		t.Errorf("style.css = %q", cssBody)
+	}
+}
+
+func TestDevServer_RedirectsDirectory(t *testing.T) {
+	t.Parallel()
+
+	opts := setupServeProject(t, `<html><body>ok</body></html>`)
+
+	docs := filepath.Join(filepath.Dir(opts.ConfigPath), "static", "docs")
+	if err := os.MkdirAll(docs, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(docs, "index.html"), []byte("<p>docs</p>"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := newDevServer(opts)
+	s.rebuild(t.Context())
+
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	client := srv.Client()
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	resp, err := client.Get(srv.URL + "/docs?v=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusMovedPermanently || resp.Header.Get("Location") != "/docs/?v=1" {
+		t.Errorf("status = %d, Location = %q, want 301 to /docs/?v=1", resp.StatusCode, resp.Header.Get("Location"))
+	}
+
+	page, err := client.Get(srv.URL + "/docs/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer page.Body.Close()
+
+	if body, _ := io.ReadAll(page.Body); !strings.Contains(string(body), "<p>docs</p>") {
+		t.Errorf("/docs/ = %d %q", page.StatusCode, body)
+	}
+}
+
+func TestDevServer_ShowsBuildError(t *testing.T) {
+	t.Parallel()
+
+	opts := setupServeProject(t, `<html><body>{{ .Page.title </body></html>`)
+
+	s := newDevServer(opts)
+	s.rebuild(t.Context())
+
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	resp, err := srv.Client().Get(srv.URL + "/index.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", resp.StatusCode)
+	}
+
+	if !strings.Contains(string(body), "Build failed") || !strings.Contains(string(body), "index.html") {
+		t.Errorf("error page missing build error:\n%s", body)
+	}
+
+	if !strings.Contains(string(body), liveReloadScript) {
+		t.Errorf("error page missing reload script:\n%s", body)
+	}
+}
+
+func TestDevServer_ReloadsOnChange(t *testing.T) {
+	t.Parallel()
+
+	opts := setupServeProject(t, `<html><body>v1</body></html>`)
+
+	s := newDevServer(opts)
+	s.rebuild(t.Context())
+
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+	defer s.close()
+
+	go s.watch(t.Context())
+
+	resp, err := srv.Client().Get(srv.URL + liveReloadPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+
+	// Give the watcher time to take its initial snapshot
+	time.Sleep(100 * time.Millisecond)
+
+	tmplPath := filepath.Join(opts.TemplateDir, "index.html")
+	if err := os.WriteFile(tmplPath, []byte(`<html><body>version two</body></html>`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	events := make(chan string, 1)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			if strings.HasPrefix(scanner.Text(), "event: ") {
+				events <- scanner.Text()
+
+				return
+			}
+		}
+	}()
+
+	select {
+	case ev := <-events:
+		if ev != "event: reload" {
+			t.Errorf("event = %q", ev)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for reload event")
+	}
+
+	// The watcher may have caught the template mid-write and rebuild again
+	var content []byte
+
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+		content, _ = os.ReadFile(filepath.Join(opts.OutputDir, "index.html"))
+		if strings.Contains(string(content), "version two") {
+			return
+		}
+	}
+
+	t.Errorf("output not rebuilt:\n%s", content)
+}
+
+func TestSnapshotChanged(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "a.txt")
+
+	if err := os.WriteFile(file, []byte("a"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	prev := snapshotFiles(dir, filepath.Join(dir, "missing"))
+	if snapshotChanged(prev, snapshotFiles(dir)) {
+		t.Error("unchanged tree reported as changed")
+	}
+
+	if err := os.WriteFile(file, []byte("ab"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if !snapshotChanged(prev, snapshotFiles(dir)) {
+		t.Error("modified file not detected")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "b.txt"), []byte("b"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if !snapshotChanged(prev, snapshotFiles(dir)) {
+		t.Error("added file not detected")
+	}
+}
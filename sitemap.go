@@ -0,0 +1,195 @@
+package ssssg
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// sitemapMaxURLs is the maximum number of URLs per sitemap file allowed by
+// the sitemap protocol. Larger sites get a sitemap index.
+const sitemapMaxURLs = 50000
+
+const sitemapNamespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+//nolint:gochecknoglobals
+var validChangeFreqs = []string{"always", "hourly", "daily", "weekly", "monthly", "yearly", "never"}
+
+func isValidChangeFreq(s string) bool {
+	return slices.Contains(validChangeFreqs, s)
+}
+
+type sitemapURL struct {
+	Loc        string `xml:"loc"`
+	ChangeFreq string `xml:"changefreq,omitempty"`
+	Priority   string `xml:"priority,omitempty"`
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapRef struct {
+	Loc string `xml:"loc"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name     `xml:"sitemapindex"`
+	XMLNS    string       `xml:"xmlns,attr"`
+	Sitemaps []sitemapRef `xml:"sitemap"`
+}
+
+// sitemapURLs lists the sitemap entries of the rendered outputs. outputs[i]
+// holds the outputs of pages[i]; paginated pages have several. Only HTML
+// outputs are listed, and pages can opt out with sitemap.exclude.
+func sitemapURLs(cfg *SitemapConfig, baseURL string, pages []PageConfig, outputs [][]string) []sitemapURL {
+	var urls []sitemapURL
+
+	for i, page := range pages {
+		changeFreq := cfg.ChangeFreq
+		priority := cfg.Priority
+
+		if page.Sitemap != nil {
+			if page.Sitemap.Exclude {
+				continue
+			}
+
+			if page.Sitemap.ChangeFreq != "" {
+				changeFreq = page.Sitemap.ChangeFreq
+			}
+
+			if page.Sitemap.Priority != nil {
+				priority = page.Sitemap.Priority
+			}
+		}
+
+		for _, output := range outputs[i] {
+			if !strings.EqualFold(path.Ext(output), ".html") {
+				continue
+			}
+
+			u := sitemapURL{
+				Loc:        absoluteURL(baseURL, outputURL(output)),
+				ChangeFreq: changeFreq,
+			}
+
+			if priority != nil {
+				u.Priority = strconv.FormatFloat(*priority, 'f', -1, 64)
+			}
+
+			urls = append(urls, u)
+		}
+	}
+
+	return urls
+}
+
+// absoluteURL joins the site base URL and a site-relative URL.
+func absoluteURL(baseURL, rel string) string {
+	return strings.TrimSuffix(baseURL, "/") + rel
+}
+
+// writeSitemap writes the sitemap to output. When there are more than limit
+// URLs they are split over output-1.xml, output-2.xml, ... and output becomes
+// a sitemap index referencing them. It returns the written files relative to
+// the output directory.
+func writeSitemap(outputDir, output, baseURL string, urls []sitemapURL, limit int) ([]string, error) {
+	if len(urls) <= limit {
+		if err := writeXML(filepath.Join(outputDir, output), sitemapURLSet{XMLNS: sitemapNamespace, URLs: urls}); err != nil {
+			return nil, err
+		}
+
+		return []string{output}, nil
+	}
+
+	files := sitemapFiles(output, len(urls), limit)
+	parts := files[:len(files)-1]
+	index := sitemapIndex{XMLNS: sitemapNamespace}
+
+	for n, part := range parts {
+		start := n * limit
+		end := min(start+limit, len(urls))
+
+		if err := writeXML(filepath.Join(outputDir, part), sitemapURLSet{XMLNS: sitemapNamespace, URLs: urls[start:end]}); err != nil {
+			return nil, err
+		}
+
+		index.Sitemaps = append(index.Sitemaps, sitemapRef{Loc: absoluteURL(baseURL, "/"+filepath.ToSlash(part))})
+	}
+
+	if err := writeXML(filepath.Join(outputDir, output), index); err != nil {
+		return nil, err
+	}
+
+	return files, nil
+}
+
+// sitemapFiles returns the files writeSitemap writes for count URLs: output
+// alone, or the numbered parts followed by the index at output.
+func sitemapFiles(output string, count, limit int) []string {
+	if count <= limit {
+		return []string{output}
+	}
+
+	ext := path.Ext(output)
+	stem := strings.TrimSuffix(output, ext)
+
+	files := make([]string, 0, (count+limit-1)/limit+1)
+	for n := 1; (n-1)*limit < count; n++ {
+		files = append(files, stem+"-"+strconv.Itoa(n)+ext)
+	}
+
+	return append(files, output)
+}
+
+// isSitemapFile reports whether p is output or one of the numbered files
+// written next to it for large sites.
+func isSitemapFile(output, p string) bool {
+	if p == output {
+		return true
+	}
+
+	ext := path.Ext(output)
+
+	number, ok := strings.CutPrefix(p, strings.TrimSuffix(output, ext)+"-")
+	if !ok {
+		return false
+	}
+
+	n, err := strconv.Atoi(strings.TrimSuffix(number, ext))
+
+	return err == nil && n >= 1 && strings.HasSuffix(number, ext)
+}
+
+func writeXML(filePath string, v any) error {
+	var buf bytes.Buffer
+
+	buf.WriteString(xml.Header)
+
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("encode %s: %w", filePath, err)
+	}
+
+	buf.WriteByte('\n')
+
+	if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+		return fmt.Errorf("create dir: %w", err)
+	}
+
+	if err := os.WriteFile(filePath, buf.Bytes(), 0o644); err != nil { //nolint:gosec
+		return fmt.Errorf("write %s: %w", filePath, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,155 @@
+package ssssg
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSitemapURLs(t *testing.T) {
+	t.Parallel()
+
+	half := 0.5
+	high := 0.85
+
+	cfg := &SitemapConfig{ChangeFreq: "weekly", Priority: &half}
+	pages := []PageConfig{
+		{Output: "index.html", Sitemap: &PageSitemapConfig{ChangeFreq: "daily", Priority: &high}},
+		{Output: "blog/index.html"},
+		{Output: "404.html", Sitemap: &PageSitemapConfig{Exclude: true}},
+		{Output: "feed.xml"},
+	}
+	outputs := [][]string{
+		{"index.html"},
+		{"blog/index.html", "blog/page/2/index.html"},
+		{"404.html"},
+		{"feed.xml"},
+	}
+
+	got := sitemapURLs(cfg, "https://example.com/", pages, outputs)
+	want := []sitemapURL{
+		{Loc: "https://example.com/", ChangeFreq: "daily", Priority: "0.85"},
+		{Loc: "https://example.com/blog/", ChangeFreq: "weekly", Priority: "0.5"},
+		{Loc: "https://example.com/blog/page/2/", ChangeFreq: "weekly", Priority: "0.5"},
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("got %d URLs, want %d: %+v", len(got), len(want), got)
+	}
+
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("urls[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestWriteSitemap_Index(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	urls := make([]sitemapURL, 5)
+	for i := range urls {
+		urls[i] = sitemapURL{Loc: "https://example.com/" + string(rune('a'+i)) + "/"}
+	}
+
+	written, err := writeSitemap(dir, "sitemap.xml", "https://example.com", urls, 2)
+	if err != nil {
+		t.Fatalf("writeSitemap failed: %v", err)
+	}
+
+	wantFiles := []string{"sitemap-1.xml", "sitemap-2.xml", "sitemap-3.xml", "sitemap.xml"}
+	if strings.Join(written, ",") != strings.Join(wantFiles, ",") {
+		t.Errorf("written = %v, want %v", written, wantFiles)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "sitemap.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var index sitemapIndex
+	if err := xml.Unmarshal(data, &index); err != nil {
+		t.Fatalf("parse index: %v", err)
+	}
+
+	if len(index.Sitemaps) != 3 || index.Sitemaps[2].Loc != "https://example.com/sitemap-3.xml" {
+		t.Errorf("index = %+v", index.Sitemaps)
+	}
+
+	data, err = os.ReadFile(filepath.Join(dir, "sitemap-3.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var set sitemapURLSet
+	if err := xml.Unmarshal(data, &set); err != nil {
+		t.Fatalf("parse sitemap: %v", err)
+	}
+
+	if len(set.URLs) != 1 || set.URLs[0].Loc != "https://example.com/e/" {
+		t.Errorf("sitemap-3.xml = %+v", set.URLs)
+	}
+}
+
+func TestBuild_WithSitemap(t *testing.T) {
+	t.Parallel()
+
+	yaml := `
+global:
+  base_url: "https://example.com"
+
+sitemap:
+  changefreq: "monthly"
+
+pages:
+  - template: "page.html"
+    output: "index.html"
+  - template: "page.html"
+    output: "about/index.html"
+  - template: "page.html"
+    output: "secret.html"
+    sitemap:
+      exclude: true
+`
+
+	dir := setupProject(t, yaml)
+
+	if err := os.WriteFile(filepath.Join(dir, "templates", "page.html"), []byte("<p>page</p>"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := Build(t.Context(), BuildOptions{
+		ConfigPath: filepath.Join(dir, "site.yaml"),
+		Timeout:    10 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "public", "sitemap.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content := string(data)
+
+	for _, want := range []string{
+		`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`,
+		"<loc>https://example.com/</loc>",
+		"<loc>https://example.com/about/</loc>",
+		"<changefreq>monthly</changefreq>",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("sitemap missing %q:\n%s", want, content)
+		}
+	}
+
+	if strings.Contains(content, "secret") {
+		t.Errorf("excluded page listed in sitemap:\n%s", content)
+	}
+}
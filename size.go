@@ -0,0 +1,135 @@
+package ssssg
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// defaultMaxBodySize bounds fetched bodies when no limit is configured.
+const defaultMaxBodySize = 100 << 20 // 100 MiB
+
+var (
+	errBodyTooLarge    = errors.New("body exceeds max_body_size")
+	errByteSizeInvalid = errors.New("invalid size")
+)
+
+// ByteSize is a size in bytes. In site.yaml it is a number of bytes or a
+// string with a unit: "512KB", "10MB", "1GB". Units are powers of 1024, and
+// "KiB", "MiB" and "GiB" are accepted too.
+type ByteSize int64
+
+// UnmarshalYAML accepts a number of bytes or a size with a unit.
+func (s *ByteSize) UnmarshalYAML(unmarshal func(any) error) error {
+	var n int64
+	if err := unmarshal(&n); err == nil {
+		*s = ByteSize(n)
+
+		return nil
+	}
+
+	var str string
+	if err := unmarshal(&str); err != nil {
+		return err
+	}
+
+	v, err := ParseByteSize(str)
+	if err != nil {
+		return err
+	}
+
+	*s = v
+
+	return nil
+}
+
+// ParseByteSize parses a size such as "10MB" or "4096".
+func ParseByteSize(str string) (ByteSize, error) {
+	s := strings.ToUpper(strings.TrimSpace(str))
+
+	multiplier := int64(1)
+
+	for _, unit := range []struct {
+		suffixes []string
+		size     int64
+	}{
+		{[]string{"GIB", "GB", "G"}, 1 << 30},
+		{[]string{"MIB", "MB", "M"}, 1 << 20},
+		{[]string{"KIB", "KB", "K"}, 1 << 10},
+		{[]string{"B"}, 1},
+	} {
+		if suffix, ok := hasAnySuffix(s, unit.suffixes); ok {
+			s = strings.TrimSpace(strings.TrimSuffix(s, suffix))
+			multiplier = unit.size
+
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 || n > (1<<62)/multiplier {
+		return 0, fmt.Errorf("%w: %q", errByteSizeInvalid, str)
+	}
+
+	return ByteSize(n * multiplier), nil
+}
+
+func hasAnySuffix(s string, suffixes []string) (string, bool) {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(s, suffix) {
+			return suffix, true
+		}
+	}
+
+	return "", false
+}
+
+// maxBodySize returns the body size limit of an entry: its own, else the
+// fetcher's, else the default. Downloads stream to disk rather than memory,
+// so they get no default and zero means unlimited.
+func (f *Fetcher) maxBodySize(entry FetchEntry) int64 {
+	switch {
+	case entry.MaxBodySize > 0:
+		return int64(entry.MaxBodySize)
+	case f.maxBody > 0:
+		return f.maxBody
+	case entry.Download != "":
+		return 0
+	default:
+		return defaultMaxBodySize
+	}
+}
+
+// checkContentLength rejects a response whose declared length exceeds limit
+// before any of the body is read. A zero limit accepts any length.
+func checkContentLength(length, limit int64) error {
+	if limit > 0 && length > limit {
+		return fmt.Errorf("%w: Content-Length %d > %d bytes", errBodyTooLarge, length, limit)
+	}
+
+	return nil
+}
+
+// copyLimited copies r to w and fails as soon as more than limit bytes are
+// read, for responses without or with a wrong Content-Length. A zero limit
+// copies everything.
+func copyLimited(w io.Writer, r io.Reader, limit int64) error {
+	if limit <= 0 {
+		_, err := io.Copy(w, r)
+
+		return err
+	}
+
+	n, err := io.Copy(w, io.LimitReader(r, limit+1))
+	if err != nil {
+		return err
+	}
+
+	if n > limit {
+		return fmt.Errorf("%w: more than %d bytes", errBodyTooLarge, limit)
+	}
+
+	return nil
+}
@@ -0,0 +1,187 @@
+package ssssg
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-yaml"
+)
+
+func TestParseByteSize(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in   string
+		want ByteSize
+	}{
+		{"4096", 4096},
+		{"512B", 512},
+		{"1K", 1 << 10},
+		{"10KB", 10 << 10},
+		{"10 MiB", 10 << 20},
+		{"2mb", 2 << 20},
+		{"1GB", 1 << 30},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseByteSize(tt.in)
+		if err != nil {
+			t.Errorf("ParseByteSize(%q): %v", tt.in, err)
+
+			continue
+		}
+
+		if got != tt.want {
+			t.Errorf("ParseByteSize(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", "MB", "-1KB", "1.5MB", "10XB", "99999999999GB"} {
+		if _, err := ParseByteSize(in); !errors.Is(err, errByteSizeInvalid) {
+			t.Errorf("ParseByteSize(%q) = %v, want errByteSizeInvalid", in, err)
+		}
+	}
+}
+
+func TestByteSize_UnmarshalYAML(t *testing.T) {
+	t.Parallel()
+
+	var cfg FetcherConfig
+	if err := yaml.Unmarshal([]byte("max_body_size: 10MB\n"), &cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.MaxBodySize != 10<<20 {
+		t.Errorf("got %d, want %d", cfg.MaxBodySize, 10<<20)
+	}
+
+	if err := yaml.Unmarshal([]byte("max_body_size: 2048\n"), &cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.MaxBodySize != 2048 {
+		t.Errorf("got %d, want 2048", cfg.MaxBodySize)
+	}
+}
+
+func TestFetcher_MaxBodySize(t *testing.T) {
+	t.Parallel()
+
+	var hits atomic.Int32
+
+	body := strings.Repeat("x", 2048)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+
+		if r.URL.Path == "/chunked" {
+			// Flushing before writing everything omits Content-Length
+			_, _ = w.Write([]byte(body[:10]))
+			w.(http.Flusher).Flush()
+		} else {
+			_, _ = w.Write([]byte(body[:10]))
+		}
+
+		_, _ = w.Write([]byte(body[10:]))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "big.txt"), []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	f := NewFetcher(dir, srv.Client(),
+		WithMaxBodySize(1024),
+		WithRetry(RetryPolicy{Retries: 2, Backoff: time.Millisecond}),
+		WithOutputDir(filepath.Join(dir, "public")),
+	)
+
+	tests := []struct {
+		name  string
+		entry FetchEntry
+		want  string // error substring, empty for success
+	}{
+		{name: "content length", entry: FetchEntry{URL: srv.URL + "/fixed"}, want: "Content-Length 2048 > 1024"},
+		{name: "streamed", entry: FetchEntry{URL: srv.URL + "/chunked"}, want: "more than 1024 bytes"},
+		{name: "download", entry: FetchEntry{URL: srv.URL + "/chunked", Download: "big.bin"}, want: "more than 1024 bytes"},
+		{name: "file", entry: FetchEntry{URL: "big.txt"}, want: "2048 > 1024"},
+		{name: "exec", entry: FetchEntry{Exec: &ExecCommand{Shell: "yes"}}, want: "more than 1024 bytes"},
+		{name: "entry limit", entry: FetchEntry{URL: srv.URL + "/allowed", MaxBodySize: 4096}},
+		{name: "file entry limit", entry: FetchEntry{URL: "big.txt", MaxBodySize: 4096}},
+		{name: "glob entry limit", entry: FetchEntry{URL: "glob:*.txt", MaxBodySize: 4096}},
+	}
+
+	for _, tt := range tests {
+		_, err := f.Resolve(context.Background(), tt.entry)
+
+		if tt.want == "" {
+			if err != nil {
+				t.Errorf("%s: %v", tt.name, err)
+			}
+
+			continue
+		}
+
+		if !errors.Is(err, errBodyTooLarge) || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: expected errBodyTooLarge with %q, got %v", tt.name, tt.want, err)
+		}
+	}
+
+	if err := f.Prefetch(context.Background(), FetchEntry{URL: "glob:*.txt", MaxBodySize: 4096}); err != nil {
+		t.Errorf("prefetch glob entry limit: %v", err)
+	}
+
+	// Oversized bodies are not retried: one request per remote entry
+	if n := hits.Load(); n != 4 {
+		t.Errorf("server was hit %d times, want 4", n)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "public", "big.bin")); !os.IsNotExist(err) {
+		t.Errorf("expected no partial download, got %v", err)
+	}
+}
+
+func TestFetcher_MaxBodySizeDownload(t *testing.T) {
+	t.Parallel()
+
+	download := FetchEntry{URL: "https://cdn.example.com/video.mp4", Download: "video.mp4"}
+	limited := download
+	limited.MaxBodySize = 4096
+
+	tests := []struct {
+		name  string
+		f     *Fetcher
+		entry FetchEntry
+		want  int64
+	}{
+		{name: "fetch default", f: NewFetcher("", nil), entry: FetchEntry{URL: download.URL}, want: defaultMaxBodySize},
+		{name: "download without limit", f: NewFetcher("", nil), entry: download, want: 0},
+		{name: "download entry limit", f: NewFetcher("", nil), entry: limited, want: 4096},
+		{name: "download fetcher limit", f: NewFetcher("", nil, WithMaxBodySize(1024)), entry: download, want: 1024},
+	}
+
+	for _, tt := range tests {
+		if got := tt.f.maxBodySize(tt.entry); got != tt.want {
+			t.Errorf("%s: maxBodySize = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+
+	// A zero limit accepts any body
+	if err := checkContentLength(1<<40, 0); err != nil {
+		t.Errorf("checkContentLength without limit: %v", err)
+	}
+
+	var buf strings.Builder
+	if err := copyLimited(&buf, strings.NewReader("large"), 0); err != nil || buf.String() != "large" {
+		t.Errorf("copyLimited without limit = %q, %v", buf.String(), err)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"time"
 
 	"golang.org/x/sync/errgroup"
@@ -17,28 +18,28 @@ type BuildOptions struct {
 	ConfigPath  string
 	TemplateDir string
 	StaticDir   string
+	ContentDir  string
+	DataDir     string
 	OutputDir   string
 	Timeout     time.Duration
 	Clean       bool
 	Log         io.Writer
 	Parallelism int
+	CacheDir    string // build and fetch cache, defaults to .ssssg-cache next to the config
+	Force       bool   // ignore the build cache and redo all work
+	Offline     bool   // serve remote fetch sources only from the fetch cache
+
+	// Fetch settings override the fetcher section of site.yaml when set
+	FetchTimeout       time.Duration // per HTTP request, separate from Timeout
+	FetchRetries       *int          // nil keeps site.yaml; 0 disables retries
+	FetchBackoff       time.Duration
+	FetchRetryStatuses []int
+	FetchMaxBodySize   int64 // bytes per response
 }
 
-func Build(ctx context.Context, opts BuildOptions) error {
-	logf := func(_ string, _ ...any) {}
-	if opts.Log != nil {
-		logf = func(format string, args ...any) {
-			fmt.Fprintf(opts.Log, format+"\n", args...)
-		}
-	}
-
-	logf("Loading config: %s", opts.ConfigPath)
-
-	cfg, err := LoadConfig(opts.ConfigPath)
-	if err != nil {
-		return fmt.Errorf("load config: %w", err)
-	}
-
+// applyDefaults fills in unset options. Directories default to siblings of
+// the config file, except ContentDir: see contentDir.
+func (opts *BuildOptions) applyDefaults() {
 	baseDir := filepath.Dir(opts.ConfigPath)
 
 	if opts.TemplateDir == "" {
@@ -49,10 +50,18 @@ func Build(ctx context.Context, opts BuildOptions) error {
 		opts.StaticDir = filepath.Join(baseDir, "static")
 	}
 
+	if opts.DataDir == "" {
+		opts.DataDir = filepath.Join(baseDir, "data")
+	}
+
 	if opts.OutputDir == "" {
 		opts.OutputDir = filepath.Join(baseDir, "public")
 	}
 
+	if opts.CacheDir == "" {
+		opts.CacheDir = filepath.Join(baseDir, ".ssssg-cache")
+	}
+
 	if opts.Timeout == 0 {
 		opts.Timeout = 30 * time.Second
 	}
@@ -60,10 +69,83 @@ func Build(ctx context.Context, opts BuildOptions) error {
 	if opts.Parallelism <= 0 {
 		opts.Parallelism = runtime.NumCPU()
 	}
+}
+
+// contentDir returns the Markdown content directory, or "" when none is
+// loaded. The default content directory is only loaded when site.yaml sets
+// content defaults, so Markdown files kept there by sites that do not use
+// content pages, such as a README.md, are not turned into pages.
+func (opts *BuildOptions) contentDir(cfg ContentConfig) string {
+	if opts.ContentDir != "" || (cfg.Template == "" && cfg.Layout == "") {
+		return opts.ContentDir
+	}
+
+	return filepath.Join(filepath.Dir(opts.ConfigPath), "content")
+}
+
+// fetcherConfig merges the fetch options into the fetcher section of the
+// config. The backoff defaults to one second.
+func (opts *BuildOptions) fetcherConfig(cfg FetcherConfig) FetcherConfig {
+	if opts.FetchTimeout > 0 {
+		cfg.Timeout = opts.FetchTimeout
+	}
+
+	if opts.FetchRetries != nil && *opts.FetchRetries >= 0 {
+		cfg.Retries = *opts.FetchRetries
+	}
+
+	if opts.FetchBackoff > 0 {
+		cfg.Backoff = opts.FetchBackoff
+	}
+
+	if len(opts.FetchRetryStatuses) > 0 {
+		cfg.RetryStatuses = opts.FetchRetryStatuses
+	}
+
+	if opts.FetchMaxBodySize > 0 {
+		cfg.MaxBodySize = ByteSize(opts.FetchMaxBodySize)
+	}
+
+	if cfg.Backoff == 0 {
+		cfg.Backoff = time.Second
+	}
+
+	return cfg
+}
+
+func Build(ctx context.Context, opts BuildOptions) error {
+	logf := func(_ string, _ ...any) {}
+	if opts.Log != nil {
+		logf = func(format string, args ...any) {
+			fmt.Fprintf(opts.Log, format+"\n", args...)
+		}
+	}
+
+	logf("Loading config: %s", opts.ConfigPath)
+
+	cfg, err := LoadConfig(opts.ConfigPath)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+
+	baseDir := filepath.Dir(opts.ConfigPath)
+	opts.applyDefaults()
+
+	// Command-line values go through the same checks as site.yaml
+	fetchCfg := opts.fetcherConfig(cfg.Fetcher)
+	if err := validateFetcher(fetchCfg); err != nil {
+		return fmt.Errorf("fetch options: %w", err)
+	}
 
 	logf("Templates: %s", opts.TemplateDir)
 	logf("Output:    %s", opts.OutputDir)
 
+	// Downloads are written as they are fetched, so check them first
+	downloads := downloadOutputs(cfg)
+	if err := checkDownloadOutputs(cfg, downloads, opts.StaticDir); err != nil {
+		return err
+	}
+
 	// Clean output directory if requested
 	if opts.Clean {
 		logf("Cleaning output directory...")
@@ -73,44 +155,159 @@ func Build(ctx context.Context, opts BuildOptions) error {
 		}
 	}
 
+	cache := loadBuildCache(opts.CacheDir, opts.Force)
+
 	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
 	defer cancel()
 
 	// HTTP client relies on context for timeout — no separate client timeout
-	fetcher := NewFetcher(baseDir, &http.Client{})
+	fetcher := NewFetcher(baseDir, &http.Client{},
+		WithCacheDir(filepath.Join(opts.CacheDir, "fetch")),
+		WithOffline(opts.Offline),
+		WithOutputDir(opts.OutputDir),
+		WithMaxBodySize(int64(fetchCfg.MaxBodySize)),
+		WithRequestTimeout(fetchCfg.Timeout),
+		WithRetry(RetryPolicy{
+			Retries:  fetchCfg.Retries,
+			Backoff:  fetchCfg.Backoff,
+			Statuses: fetchCfg.RetryStatuses,
+		}),
+		WithLogf(logf),
+	)
+
+	if opts.Offline {
+		logf("Offline: serving remote sources from the fetch cache")
+	}
+
+	// Collect all unique fetch sources. A request used with several TTLs is
+	// fetched with the shortest one. Log lines never show secrets.
+	sources := make(map[string]FetchEntry)
+	addSource := func(src FetchEntry) {
+		key := src.cacheKey()
+		if prev, ok := sources[key]; ok && prev.TTL <= src.TTL {
+			return
+		}
+
+		sources[key] = src
+	}
 
-	// Collect all unique fetch sources
-	sources := make(map[string]struct{})
 	for key, src := range cfg.Global.Fetch {
-		sources[src] = struct{}{}
-		logf("Fetching global.%s: %s", key, src)
+		addSource(src)
+		logf("Fetching global.%s: %s", key, src.describe())
 	}
 
 	for _, page := range cfg.Pages {
 		for key, src := range page.Fetch {
-			sources[src] = struct{}{}
-			logf("Fetching %s.%s: %s", page.Output, key, src)
+			addSource(src)
+			logf("Fetching %s.%s: %s", page.Output, key, src.describe())
 		}
 	}
 
-	// Prefetch all unique sources in parallel
-	if len(sources) > 0 {
-		logf("Fetching %d source(s) in parallel...", len(sources))
+	for i, c := range cfg.Collections {
+		if c.Source != nil {
+			addSource(*c.Source)
+			logf("Fetching collections[%d]: %s", i, c.Source.describe())
+		}
+	}
+
+	// Prefetch all unique sources in parallel. Downloads wait until every
+	// output they could be written over is known.
+	prefetch := func(download bool) error {
+		var entries []FetchEntry
+
+		for _, src := range sources {
+			if (len(downloadPaths(src)) > 0) == download {
+				entries = append(entries, src)
+			}
+		}
+
+		if len(entries) == 0 {
+			return nil
+		}
+
+		logf("Fetching %d source(s) in parallel...", len(entries))
 
 		g, gctx := errgroup.WithContext(ctx)
 		g.SetLimit(opts.Parallelism)
 
-		for src := range sources {
+		for _, src := range entries {
 			g.Go(func() error {
-				_, err := fetcher.Fetch(gctx, src)
-
-				return err
+				return fetcher.Prefetch(gctx, src)
 			})
 		}
 
 		if err := g.Wait(); err != nil {
 			return fmt.Errorf("fetch: %w", err)
 		}
+
+		return nil
+	}
+
+	if err := prefetch(false); err != nil {
+		return err
+	}
+
+	// Files in the data directory are shared by every page as .Data
+	siteData, err := LoadDataDir(opts.DataDir)
+	if err != nil {
+		return fmt.Errorf("load data: %w", err)
+	}
+
+	if len(siteData) > 0 {
+		logf("Data: %d key(s) from %s", len(siteData), opts.DataDir)
+	}
+
+	// Expand collections into one page per item
+	pages := cfg.Pages
+	collectionSpans := make(map[string][2]int, len(cfg.Collections)) // name -> [start, end) in pages
+
+	for i, c := range cfg.Collections {
+		items, err := collectionItems(ctx, fetcher, c)
+		if err != nil {
+			return fmt.Errorf("collections[%d]: %w", i, err)
+		}
+
+		generated, err := expandCollection(c, items)
+		if err != nil {
+			return fmt.Errorf("collections[%d]: %w", i, err)
+		}
+
+		logf("Collection %s: %d page(s)", collectionName(c, i), len(generated))
+
+		if c.Name != "" {
+			collectionSpans[c.Name] = [2]int{len(pages), len(pages) + len(generated)}
+		}
+
+		pages = append(pages, generated...)
+	}
+
+	// Markdown files in the content directory become pages too
+	var contentPages []PageConfig
+
+	if dir := opts.contentDir(cfg.Content); dir != "" {
+		contentPages, err = LoadContentPages(dir, cfg.Content)
+		if err != nil {
+			return fmt.Errorf("load content: %w", err)
+		}
+	}
+
+	if len(contentPages) > 0 {
+		logf("Content: %d page(s)", len(contentPages))
+
+		pages = append(pages, contentPages...)
+	}
+
+	generated := slices.Clone(downloads)
+	for _, page := range pages[len(cfg.Pages):] {
+		generated = append(generated, plannedOutput{path: page.Output, owner: "page " + page.Output})
+	}
+
+	if err := checkDuplicateOutputs(generated); err != nil {
+		return err
+	}
+
+	if err := prefetch(true); err != nil {
+		return err
 	}
 
 	// Build global data from data + cached fetch results
@@ -120,7 +317,7 @@ func Build(ctx context.Context, opts BuildOptions) error {
 	}
 
 	for key, src := range cfg.Global.Fetch {
-		content, err := fetcher.Fetch(ctx, src)
+		content, err := fetcher.Resolve(ctx, src)
 		if err != nil {
 			return fmt.Errorf("resolve global fetch %q: %w", key, err)
 		}
@@ -128,17 +325,114 @@ func Build(ctx context.Context, opts BuildOptions) error {
 		globalData[key] = content
 	}
 
+	// Resolve the data of every page up front so templates can list all pages
+	infos := make([]*PageInfo, len(pages))
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(opts.Parallelism)
+
+	for i, page := range pages {
+		g.Go(func() error {
+			pageData := make(map[string]any)
+			for k, v := range page.Data {
+				pageData[k] = v
+			}
+
+			for key, src := range page.Fetch {
+				content, err := fetcher.Resolve(gctx, src)
+				if err != nil {
+					return fmt.Errorf("resolve %s fetch %q: %w", page.Output, key, err)
+				}
+
+				pageData[key] = content
+			}
+
+			info := newPageInfo(page)
+			info.Data = pageData
+			infos[i] = info
+
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return fmt.Errorf("resolve pages: %w", err)
+	}
+
+	collectionInfos := make(map[string][]*PageInfo, len(collectionSpans))
+	for name, span := range collectionSpans {
+		collectionInfos[name] = infos[span[0]:span[1]]
+	}
+
+	feedInfos := newFeedInfos(cfg.Feeds)
+
+	// Expand pagination now so that every output is known before anything
+	// is written over another.
+	pagers := make([][]*Paginator, len(pages))
+	outputs := make([][]string, len(pages)) // outputs per page, for the sitemap
+	planned := make([]plannedOutput, 0, len(pages))
+
+	for i, page := range pages {
+		if page.Paginate == nil {
+			outputs[i] = []string{page.Output}
+		} else {
+			items, err := paginationItems(page.Paginate, infos[i].Data, collectionInfos)
+			if err != nil {
+				return fmt.Errorf("paginate %s: %w", page.Output, err)
+			}
+
+			pagers[i] = paginate(items, page.Paginate.PerPage, page.Output)
+			for _, pager := range pagers[i] {
+				outputs[i] = append(outputs[i], paginatedOutput(page.Output, pager.Number))
+			}
+		}
+
+		for _, output := range outputs[i] {
+			planned = append(planned, plannedOutput{path: output, owner: "page " + page.Output})
+		}
+	}
+
+	var (
+		sitemapOutput  string
+		sitemapEntries []sitemapURL
+	)
+
+	if cfg.Sitemap != nil {
+		sitemapOutput = cfg.Sitemap.Output
+		if sitemapOutput == "" {
+			sitemapOutput = "sitemap.xml"
+		}
+
+		sitemapEntries = sitemapURLs(cfg.Sitemap, cfg.Global.BaseURL, pages, outputs)
+
+		for _, file := range sitemapFiles(sitemapOutput, len(sitemapEntries), sitemapMaxURLs) {
+			planned = append(planned, plannedOutput{path: file, owner: "sitemap"})
+		}
+	}
+
+	for _, f := range cfg.Feeds {
+		for _, output := range []string{f.RSS, f.Atom} {
+			if output != "" {
+				planned = append(planned, plannedOutput{path: output, owner: "feed " + f.Name})
+			}
+		}
+	}
+
+	if err := checkDuplicateOutputs(append(planned, downloads...)); err != nil {
+		return err
+	}
+
 	// Process static files first (before rendering, so templates can access metadata)
 	logf("Processing static files...")
 
-	if err := ProcessStatic(ctx, opts.StaticDir, opts.OutputDir, cfg.Static.Pipelines, opts.Parallelism); err != nil {
+	if err := processStatic(ctx, opts.StaticDir, opts.OutputDir, cfg.Static.Pipelines, opts.Parallelism, cache); err != nil {
 		return fmt.Errorf("process static: %w", err)
 	}
 
 	// Scan processed static files for metadata
 	logf("Scanning static files...")
 
-	staticMeta, err := ScanStaticFiles(opts.OutputDir, opts.Parallelism)
+	staticMeta, err := scanStaticFiles(opts.OutputDir, opts.Parallelism, cache)
 	if err != nil {
 		return fmt.Errorf("scan static files: %w", err)
 	}
@@ -149,39 +443,98 @@ func Build(ctx context.Context, opts BuildOptions) error {
 
 	logf("  Found %d static file(s)", len(staticMeta))
 
+	// Fingerprint the inputs shared by every page once
+	tmplHashes, err := templateHashes(opts.TemplateDir, pages)
+	if err != nil {
+		return fmt.Errorf("hash templates: %w", err)
+	}
+
+	// Fingerprint each shared input separately; a page only depends on the
+	// ones its templates use.
+	sharedHashes := map[string]string{
+		"Global": hashJSON(globalData),
+		"Data":   hashJSON(siteData),
+		"Static": hashJSON(staticInputs(staticMeta, pages, cache)),
+		"Pages":  hashJSON(infos),
+		"Feeds":  hashJSON(feedInfos),
+	}
+
+	renderer, err := NewRenderer(opts.TemplateDir)
+	if err != nil {
+		return fmt.Errorf("load templates: %w", err)
+	}
+
 	// Render each page in parallel
-	logf("Building %d page(s)...", len(cfg.Pages))
+	logf("Building %d page(s)...", len(pages))
 
-	g, gctx := errgroup.WithContext(ctx)
+	g = new(errgroup.Group)
 	g.SetLimit(opts.Parallelism)
 
-	for _, page := range cfg.Pages {
+	for i, page := range pages {
 		g.Go(func() error {
-			pageData := make(map[string]any)
-			for k, v := range page.Data {
-				pageData[k] = v
+			current := infos[i]
+			pageData := current.Data
+
+			layout := page.Layout
+			if layout == "" {
+				layout = cfg.Global.Layout
 			}
 
-			for key, src := range page.Fetch {
-				content, err := fetcher.Fetch(gctx, src)
-				if err != nil {
-					return fmt.Errorf("resolve %s fetch %q: %w", page.Output, key, err)
-				}
+			pageHash := hashJSON(pageData)
 
-				pageData[key] = content
+			// A template that fails to parse has no fingerprint; Render reports it.
+			sharedHash := ""
+			if used, err := renderer.usedFields(page.Template); err == nil {
+				sharedHash = sharedFingerprint(sharedHashes, used)
 			}
 
-			data := TemplateData{
-				Global: globalData,
-				Page:   pageData,
-				Static: staticMeta,
+			// renderOutput renders one output of the page, skipping it when
+			// its inputs are unchanged since the previous build.
+			renderOutput := func(out PageConfig, paginator *Paginator) error {
+				fingerprint := ""
+				if pagerHash := hashJSON(paginator); sharedHash != "" && pageHash != "" && pagerHash != "" {
+					fingerprint = hashStrings(tmplHashes[page.Template], layout, out.Output, sharedHash, pageHash, pagerHash, string(page.content))
+				}
+
+				if cache.pageFresh(out.Output, fingerprint, filepath.Join(opts.OutputDir, out.Output)) {
+					logf("  Unchanged: %s", out.Output)
+
+					return nil
+				}
+
+				data := TemplateData{
+					Global:    globalData,
+					Data:      siteData,
+					Page:      pageData,
+					Static:    staticMeta,
+					Paginator: paginator,
+					Content:   page.content,
+					Pages:     infos,
+					Current:   current,
+					Feeds:     feedInfos,
+				}
+
+				if err := renderer.Render(out, cfg.Global.Layout, data, opts.OutputDir); err != nil {
+					return fmt.Errorf("render %s: %w", out.Output, err)
+				}
+
+				logf("  Generated: %s", out.Output)
+
+				return nil
 			}
 
-			if err := RenderPage(opts.TemplateDir, page, cfg.Global.Layout, data, opts.OutputDir); err != nil {
-				return fmt.Errorf("render %s: %w", page.Output, err)
+			if page.Paginate == nil {
+				return renderOutput(page, nil)
 			}
 
-			logf("  Generated: %s", page.Output)
+			for _, pager := range pagers[i] {
+				out := page
+				out.Output = paginatedOutput(page.Output, pager.Number)
+
+				if err := renderOutput(out, pager); err != nil {
+					return err
+				}
+			}
 
 			return nil
 		})
@@ -191,6 +544,38 @@ func Build(ctx context.Context, opts BuildOptions) error {
 		return fmt.Errorf("build pages: %w", err)
 	}
 
+	if cfg.Sitemap != nil {
+		written, err := writeSitemap(opts.OutputDir, sitemapOutput, cfg.Global.BaseURL, sitemapEntries, sitemapMaxURLs)
+		if err != nil {
+			return fmt.Errorf("write sitemap: %w", err)
+		}
+
+		for _, file := range written {
+			cache.recordGenerated(file)
+		}
+
+		logf("Sitemap: %s (%d URL(s))", sitemapOutput, len(sitemapEntries))
+	}
+
+	for _, f := range cfg.Feeds {
+		items := feedItems(f, cfg.Global.BaseURL, feedPages(f, infos, collectionInfos))
+
+		written, err := writeFeed(opts.OutputDir, f, cfg.Global.BaseURL, items)
+		if err != nil {
+			return fmt.Errorf("write feed %s: %w", f.Name, err)
+		}
+
+		for _, file := range written {
+			cache.recordGenerated(file)
+		}
+
+		logf("Feed %s: %d item(s)", f.Name, len(items))
+	}
+
+	if err := cache.save(); err != nil {
+		return fmt.Errorf("save build cache: %w", err)
+	}
+
 	logf("Done!")
 
 	return nil
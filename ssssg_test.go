@@ -1,6 +1,7 @@
 package ssssg
 
 import (
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -476,3 +477,156 @@ static:
 		t.Errorf("style.css content = %q, want %q", string(cssContent), "body{}")
 	}
 }
+
+func TestBuild_WithStructuredFetch(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[{"name": "ssssg"}, {"name": "other"}]`))
+	}))
+	defer srv.Close()
+
+	yaml := `
+global:
+  fetch:
+    team:
+      url: "team.csv"
+      format: "auto"
+
+pages:
+  - template: "index.html"
+    output: "index.html"
+    fetch:
+      projects:
+        url: "` + srv.URL + `"
+        format: "json"
+`
+
+	dir := setupProject(t, yaml)
+
+	if err := os.WriteFile(filepath.Join(dir, "team.csv"), []byte("name,role\nalice,dev\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := `{{ range .Page.projects }}<li>{{ .name }}</li>{{ end }}{{ range .Global.team }}<p>{{ .name }}:{{ .role }}</p>{{ end }}`
+	if err := os.WriteFile(filepath.Join(dir, "templates", "index.html"), []byte(tmpl), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := Build(t.Context(), BuildOptions{
+		ConfigPath: filepath.Join(dir, "site.yaml"),
+		Timeout:    10 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, "public", "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "<li>ssssg</li><li>other</li><p>alice:dev</p>"
+	if string(content) != want {
+		t.Errorf("content = %q, want %q", string(content), want)
+	}
+}
+
+func TestBuild_WithPagesAndCurrent(t *testing.T) {
+	t.Parallel()
+
+	yaml := `
+global:
+  layout: "_layout.html"
+
+pages:
+  - template: "index.html"
+    output: "index.html"
+    data:
+      title: "Home"
+  - template: "about.html"
+    output: "about/index.html"
+    data:
+      title: "About"
+`
+
+	dir := setupProject(t, yaml)
+
+	files := map[string]string{
+		"_layout.html": `<nav>{{ range .Pages }}` +
+			`<a href="{{ .URL }}"{{ if eq .Output $.Current.Output }} class="active"{{ end }}>{{ .Data.title }}</a>` +
+			`{{ end }}</nav>{{ block "content" . }}{{ end }}`,
+		"index.html": `{{ define "content" }}<h1>{{ .Current.Data.title }}</h1>{{ end }}`,
+		"about.html": `{{ define "content" }}<h1>{{ .Current.Template }}</h1>{{ end }}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, "templates", name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := Build(t.Context(), BuildOptions{
+		ConfigPath: filepath.Join(dir, "site.yaml"),
+		Timeout:    10 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	tests := []struct {
+		output string
+		want   string
+	}{
+		{
+			output: "index.html",
+			want:   `<nav><a href="/" class="active">Home</a><a href="/about/">About</a></nav><h1>Home</h1>`,
+		},
+		{
+			output: "about/index.html",
+			want:   `<nav><a href="/">Home</a><a href="/about/" class="active">About</a></nav><h1>about.html</h1>`,
+		},
+	}
+
+	for _, tt := range tests {
+		content, err := os.ReadFile(filepath.Join(dir, "public", tt.output))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(content) != tt.want {
+			t.Errorf("%s = %q, want %q", tt.output, string(content), tt.want)
+		}
+	}
+}
+
+func TestBuildOptions_FetcherConfig(t *testing.T) {
+	t.Parallel()
+
+	site := FetcherConfig{Retries: 3}
+	zero := 0
+
+	if got := (&BuildOptions{}).fetcherConfig(site); got.Retries != 3 {
+		t.Errorf("unset retries = %d, want site.yaml 3", got.Retries)
+	}
+
+	if got := (&BuildOptions{FetchRetries: &zero}).fetcherConfig(site); got.Retries != 0 {
+		t.Errorf("explicit retries = %d, want 0", got.Retries)
+	}
+}
+
+func TestBuild_InvalidFetchRetryStatus(t *testing.T) {
+	t.Parallel()
+
+	dir := setupProject(t, "pages: []\n")
+
+	for _, status := range []int{0, 200} {
+		err := Build(t.Context(), BuildOptions{
+			ConfigPath:         filepath.Join(dir, "site.yaml"),
+			FetchRetryStatuses: []int{status},
+		})
+		if !errors.Is(err, errRetryStatusInvalid) {
+			t.Errorf("status %d: err = %v, want errRetryStatusInvalid", status, err)
+		}
+	}
+}
@@ -21,6 +21,13 @@ import (
 // via image.DecodeConfig (header-only, fast). Errors on individual files are
 // silently ignored so that a broken image never stops the build.
 func ScanStaticFiles(dir string, parallelism int) (map[string]StaticFileInfo, error) {
+	return scanStaticFiles(dir, parallelism, nil)
+}
+
+// scanStaticFiles is ScanStaticFiles with an optional build cache. Files with
+// the same size and modification time as in the previous build reuse the
+// metadata recorded then instead of being decoded again.
+func scanStaticFiles(dir string, parallelism int, cache *buildCache) (map[string]StaticFileInfo, error) {
 	info, err := os.Stat(dir)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -37,6 +44,7 @@ func ScanStaticFiles(dir string, parallelism int) (map[string]StaticFileInfo, er
 	type fileEntry struct {
 		path    string
 		relPath string
+		info    fs.FileInfo
 	}
 
 	var files []fileEntry
@@ -60,7 +68,12 @@ func ScanStaticFiles(dir string, parallelism int) (map[string]StaticFileInfo, er
 			return fmt.Errorf("relative path: %w", err)
 		}
 
-		files = append(files, fileEntry{path: path, relPath: relPath})
+		fi, err := d.Info()
+		if err != nil {
+			return fmt.Errorf("stat %s: %w", relPath, err)
+		}
+
+		files = append(files, fileEntry{path: path, relPath: relPath, info: fi})
 
 		return nil
 	})
@@ -76,10 +89,17 @@ func ScanStaticFiles(dir string, parallelism int) (map[string]StaticFileInfo, er
 
 	for _, f := range files {
 		g.Go(func() error {
-			si := scanFile(f.path, f.relPath)
+			key := filepath.ToSlash(f.relPath)
+
+			si, ok := cache.cachedScan(key, f.info)
+			if !ok {
+				si = scanFile(f.path, f.relPath)
+			}
+
+			cache.recordScan(key, f.info, si)
 
 			mu.Lock()
-			result[filepath.ToSlash(f.relPath)] = si
+			result[key] = si
 			mu.Unlock()
 
 			return nil